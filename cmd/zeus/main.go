@@ -8,12 +8,32 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/labstack/echo/v4"
 
 	"github.com/neoxelox/zeus/internal/server"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		var err error
+
+		switch os.Args[1] {
+		case "migrate":
+			err = migrateCommand(os.Args[2:])
+		case "seed":
+			err = seedCommand(os.Args[2:])
+		default:
+			err = errors.Newf("Unknown command %q", os.Args[1])
+		}
+
+		if err != nil {
+			exit(err)
+		}
+
+		return
+	}
+
 	instance := echo.New()
 	zeus := server.New(instance)
 	go zeus.Startup()
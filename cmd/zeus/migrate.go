@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/internal/logger"
+	"github.com/neoxelox/zeus/internal/server"
+	"github.com/neoxelox/zeus/migrations"
+)
+
+const migrateUsage = `Usage: zeus migrate [-dir DIRECTORY] <command> [arguments]
+
+Flags:
+  -dir DIRECTORY  Read the migrations from DIRECTORY instead of the embedded ones,
+                  overriding DATABASE_MIGRATIONS_DIRECTORY
+
+Commands:
+  up [N]       Apply the next N migrations, or every pending one
+  down N|-all  Revert the last N migrations, or every applied one with -all
+  goto V       Apply or revert migrations until version V
+  version      Print the current version of the database
+  force V      Set the version of the database to V without running migrations,
+               or to -1 to mark it as without any migration applied
+  status       List the applied and pending migrations
+  create NAME  Create the up and down files of a new migration
+  lint         Check the migrations for destructive or locking operations
+  drift        Compare the live schema with the one produced by the migrations
+`
+
+// migrateCommand runs the migrate subcommand with the given arguments.
+func migrateCommand(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+	directory := flags.String("dir", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return err // nolint
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return errors.New("Missing migrate command")
+	}
+
+	command, args := flags.Arg(0), flags.Args()[1:]
+
+	if command == "create" {
+		if len(args) != 1 {
+			return errors.New("Usage: zeus migrate create NAME")
+		}
+
+		if *directory == "" {
+			*directory = database.MigrationsDirectory
+		}
+
+		paths, err := database.CreateMigration(*directory, args[0])
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+
+		return nil
+	}
+
+	if command == "lint" {
+		return lintMigrations(*directory)
+	}
+
+	switch command {
+	case "up", "down", "goto", "force", "version", "status", "drift":
+	default:
+		flags.Usage()
+
+		return errors.Newf("Unknown migrate command %q", command)
+	}
+
+	configuration := server.NewConfiguration()
+	appLogger := logger.New(configuration.App.Name)
+
+	if *directory != "" {
+		configuration.Database.MigrationsDirectory = *directory
+	}
+
+	if command == "drift" {
+		return detectDrift(server.DatabaseConfiguration(configuration, appLogger))
+	}
+
+	ctx := context.Background()
+
+	db, err := database.New(ctx, 1, server.DatabaseConfiguration(configuration, appLogger))
+	if err != nil {
+		return err
+	}
+	defer db.Close(ctx) // nolint
+
+	migrator, err := database.NewMigrator(server.DatabaseConfiguration(configuration, appLogger))
+	if err != nil {
+		return err
+	}
+	defer migrator.Close() // nolint
+
+	// Commands changing the version hold the migration lock, so that they do not race with
+	// instances migrating at startup.
+	locked := func(fn func() error) error {
+		return db.WithMigrationLock(ctx, fn)
+	}
+
+	switch command {
+	case "up":
+		n, err := upSteps(args)
+		if err != nil {
+			return err
+		}
+
+		return locked(func() error { return migrator.Up(ctx, n) })
+	case "down":
+		n, all, err := downSteps(args)
+		if err != nil {
+			return err
+		}
+
+		if all {
+			return locked(func() error { return migrator.DownAll(ctx) })
+		}
+
+		return locked(func() error { return migrator.Down(ctx, n) })
+	case "goto":
+		version, err := requiredNumber(args)
+		if err != nil {
+			return err
+		}
+
+		return locked(func() error { return migrator.Goto(ctx, uint(version)) })
+	case "force":
+		version, err := forceVersion(args)
+		if err != nil {
+			return err
+		}
+
+		return locked(func() error { return migrator.Force(version) })
+	case "version":
+		version, dirty, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+
+		return nil
+	default:
+		return printStatus(migrator)
+	}
+}
+
+func printStatus(migrator *database.Migrator) error {
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	migrations, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		status := "pending"
+
+		switch {
+		case dirty && migration.Version == version:
+			status = "dirty"
+		case migration.Applied:
+			status = "applied"
+		}
+
+		fmt.Printf("%04d  %-8s %s\n", migration.Version, status, migration.Name)
+	}
+
+	return nil
+}
+
+// lintMigrations prints the issues of the migrations in directory, or of the embedded ones,
+// failing if there is any so that it can be used in CI.
+func lintMigrations(directory string) error {
+	var fsys fs.FS = migrations.FS
+	if directory != "" {
+		fsys = os.DirFS(directory)
+	}
+
+	issues, err := database.LintMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("%s: [%s] %s\n", issue.File, issue.Rule, issue.Message)
+		if issue.Statement != "" {
+			fmt.Printf("    %s\n", issue.Statement)
+		}
+	}
+
+	if len(issues) > 0 {
+		return errors.Newf("Found %d issues in the migrations, silence false positives with %s <rule>",
+			len(issues), database.LintIgnoreDirective)
+	}
+
+	return nil
+}
+
+// detectDrift prints the differences between the live schema and the one produced by the migrations,
+// failing if there is any.
+func detectDrift(configuration database.Configuration) error {
+	ctx := context.Background()
+
+	db, err := database.New(ctx, 1, configuration)
+	if err != nil {
+		return err
+	}
+	defer db.Close(ctx) // nolint
+
+	drifts, err := db.DetectDrift(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, drift := range drifts {
+		fmt.Println(drift.String())
+	}
+
+	if len(drifts) > 0 {
+		return errors.Newf("Found %d differences between the database schema and the migrations", len(drifts))
+	}
+
+	return nil
+}
+
+// downSteps parses the arguments of the down command, which requires either a positive number
+// of migrations or the -all flag, so that a bare down does not revert the whole schema.
+func downSteps(args []string) (int, bool, error) {
+	flags := flag.NewFlagSet("down", flag.ContinueOnError)
+	all := flags.Bool("all", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 0, false, err // nolint
+	}
+
+	if *all {
+		if flags.NArg() > 0 {
+			return 0, false, errors.New("Usage: zeus migrate down N|-all")
+		}
+
+		return 0, true, nil
+	}
+
+	n, err := requiredNumber(flags.Args())
+	if err != nil {
+		return 0, false, errors.Wrap(err, "Usage: zeus migrate down N|-all")
+	}
+
+	if n == 0 {
+		return 0, false, errors.New("Expected a positive number of migrations to revert, or -all to revert every one")
+	}
+
+	return n, false, nil
+}
+
+// upSteps parses the arguments of the up command, which takes an optional positive number of migrations,
+// applying every pending one without it.
+func upSteps(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+
+	n, err := requiredNumber(args)
+	if err != nil {
+		return 0, errors.Wrap(err, "Usage: zeus migrate up [N]")
+	}
+
+	if n == 0 {
+		return 0, errors.New("Expected a positive number of migrations to apply, or none to apply every one")
+	}
+
+	return n, nil
+}
+
+// forceVersion parses the arguments of the force command, which requires a version or -1,
+// the version of a database without any migration applied, to recover from a failed first migration.
+func forceVersion(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("Usage: zeus migrate force V")
+	}
+
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < -1 {
+		return 0, errors.Newf("Invalid version %q, expected a version or -1", args[0])
+	}
+
+	return version, nil
+}
+
+func requiredNumber(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("Expected a single numeric argument")
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return 0, errors.Newf("Invalid numeric argument %q", args[0])
+	}
+
+	return n, nil
+}
+
+// exit prints the error of a subcommand and exits with a non-zero status.
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "%v\n", err)
+	os.Exit(1)
+}
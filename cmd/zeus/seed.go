@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/cockroachdb/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/internal/logger"
+	"github.com/neoxelox/zeus/internal/server"
+	"github.com/neoxelox/zeus/internal/validator"
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/payload"
+	"github.com/neoxelox/zeus/pkg/repository"
+	"github.com/neoxelox/zeus/pkg/user"
+)
+
+const seedUsage = `Usage: zeus seed [-truncate] [-users N] [FIXTURE...]
+
+Seeds the database with the users of the given YAML or JSON fixture files,
+shaped as {"users": [{"name": ..., "username": ..., "age": ...}]}, and N
+random valid users, within a single transaction. Neither the seeded nor the
+removed users emit events, so no webhook is notified.
+
+Flags:
+  -truncate  Remove every existing user before seeding
+  -users N   Generate N random users with unique usernames
+`
+
+// seedCommand runs the seed subcommand with the given arguments.
+func seedCommand(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), seedUsage) }
+	truncate := flags.Bool("truncate", false, "")
+	random := flags.Int("users", 0, "")
+
+	if err := flags.Parse(args); err != nil {
+		return err // nolint
+	}
+
+	if *random < 0 {
+		return errors.Newf("Invalid number of users %d", *random)
+	}
+
+	if flags.NArg() == 0 && *random == 0 && !*truncate {
+		flags.Usage()
+
+		return errors.New("Nothing to seed")
+	}
+
+	users := []*model.User{}
+
+	for _, path := range flags.Args() {
+		fixture, err := readFixture(path)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range fixture.Users {
+			users = append(users, model.NewUser(record.Name, record.Username, record.Age))
+		}
+	}
+
+	configuration := server.NewConfiguration()
+	appLogger := logger.New(configuration.App.Name)
+	ctx := context.Background()
+
+	db, err := database.New(ctx, 1, server.DatabaseConfiguration(configuration, appLogger))
+	if err != nil {
+		return err
+	}
+	defer db.Close(ctx) // nolint
+
+	userDatabase := repository.NewUserDatabase(db)
+
+	if err := db.Prepare(ctx); err != nil {
+		return err
+	}
+
+	seeder := user.NewSeeder(userDatabase)
+	users = append(users, seeder.Generate(*random)...)
+
+	if err := seeder.Seed(ctx, users, *truncate); err != nil {
+		return err
+	}
+
+	fmt.Printf("Seeded %d users\n", len(users))
+
+	return nil
+}
+
+// readFixture decodes and validates a fixture file, as YAML or JSON depending on its extension.
+func readFixture(path string) (*payload.UserSeedFixture, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot read fixture %s", path)
+	}
+
+	fixture := &payload.UserSeedFixture{}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(content, fixture)
+	case ".json":
+		err = json.Unmarshal(content, fixture)
+	default:
+		return nil, errors.Newf("Fixture %s must be a .yaml, .yml or .json file", path)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot decode fixture %s", path)
+	}
+
+	if err := validator.New().Validate(fixture); err != nil {
+		return nil, errors.Wrapf(err, "Invalid fixture %s", path)
+	}
+
+	return fixture, nil
+}
@@ -6,18 +6,14 @@ require (
 	github.com/cockroachdb/errors v1.8.3
 	github.com/go-playground/validator/v10 v10.5.0
 	github.com/golang-migrate/migrate/v4 v4.14.1
-	github.com/jackc/pgconn v1.8.1
+	github.com/jackc/pgconn v1.13.0
 	github.com/jackc/pgerrcode v0.0.0-20201024163028-a0d42d470451
-	github.com/jackc/pgproto3/v2 v2.0.7 // indirect
-	github.com/jackc/pgx/v4 v4.11.0
+	github.com/jackc/pgx/v4 v4.17.2
 	github.com/jackc/pgxutil v0.0.0-20200703204206-37866e09a15b
 	github.com/labstack/echo/v4 v4.2.2
 	github.com/labstack/gommon v0.3.0
 	github.com/mattn/go-colorable v0.1.8 // indirect
 	github.com/rs/xid v1.3.0
 	github.com/rs/zerolog v1.21.0
-	github.com/stretchr/testify v1.7.0 // indirect
-	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
-	golang.org/x/sys v0.0.0-20210403161142-5e06dd20ab57 // indirect
-	golang.org/x/text v0.3.6 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
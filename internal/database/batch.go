@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgx/v4"
+)
+
+// ExecBatch pipelines the statements of the batch in a single round trip, discarding their results.
+// The statements run in the transaction of the context, if any, or otherwise in the implicit transaction
+// of the single Sync the batch is sent with, so either all of them are applied or none.
+func ExecBatch(ctx context.Context, cn Connection, batch *pgx.Batch) error {
+	results := cn.SendBatch(ctx, batch)
+
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := results.Exec(); err != nil {
+			results.Close() // nolint
+
+			return errors.Wrapf(Error(err), "Error within statement %d of the batch", i)
+		}
+	}
+
+	if err := results.Close(); err != nil {
+		return Error(err)
+	}
+
+	return nil
+}
+
+// Copy bulk inserts the rows into the columns of the table using the COPY protocol,
+// returning how many rows were copied. Either every row is copied or none is.
+func Copy(ctx context.Context, cn Connection, table string, columns []string, rows [][]interface{}) (int64, error) {
+	count, err := cn.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+	if err != nil {
+		return 0, Error(err)
+	}
+
+	return count, nil
+}
@@ -3,12 +3,10 @@ package database
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/errors"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres" // nolint
-	_ "github.com/golang-migrate/migrate/v4/source/file"       // nolint
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -27,29 +25,141 @@ type Configuration struct {
 	AppName  string
 	Logger   pgx.Logger
 	LogLevel pgx.LogLevel
+
+	ConnectMinBackoff time.Duration
+	ConnectMaxBackoff time.Duration
+
+	TransactionAttempts   int
+	TransactionMinBackoff time.Duration
+	TransactionMaxBackoff time.Duration
+
+	Replicas             []string // Either host:port addresses sharing the primary settings or connection strings.
+	ReplicaCheckPeriod   time.Duration
+	MaxReplicaLag        time.Duration
+	ReadYourWritesWindow time.Duration
+
+	StatementTimeout   time.Duration
+	SlowQueryThreshold time.Duration
+
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+
+	MigrationsDirectory  string
+	MigrationLockTimeout time.Duration
+
+	Statements []Statement
 }
 
 // Database describes the database.
 type Database struct {
+	timeouts      uint64 // First to be 64-bit aligned for atomic operations on 32-bit platforms.
 	Connection    *pgxpool.Pool
+	Replicas      []*Replica
 	configuration Configuration
+	statements    *statementRegistry
+	next          uint32
+	stop          chan struct{}
+	closed        sync.Once
 }
 
-// New creates a new Database instance.
+// New creates a new Database instance, trying to connect up to retries times with exponential
+// backoff between ConnectMinBackoff and ConnectMaxBackoff until the context is done.
 func New(ctx context.Context, retries int, configuration Configuration) (*Database, error) {
-	delay := time.NewTicker(1 * time.Second)
-	timeout := (time.Duration(retries) * time.Second)
-	defer delay.Stop()
+	if configuration.ConnectMinBackoff <= 0 {
+		configuration.ConnectMinBackoff = 500 * time.Millisecond // nolint
+	}
+
+	if configuration.ConnectMaxBackoff <= 0 {
+		configuration.ConnectMaxBackoff = 10 * time.Second // nolint
+	}
+
+	statements, err := newStatementRegistry(configuration.Statements)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := poolConfig(configuration, connectionString(configuration,
+		fmt.Sprintf("%s:%d", configuration.Host, configuration.Port)), statements)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := connectReplicas(ctx, configuration, statements)
+	if err != nil {
+		return nil, err
+	}
+
+	closeReplicas := func() {
+		for _, replica := range replicas {
+			replica.Connection.Close()
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Trying to connect to the database",
+			map[string]interface{}{"attempt": attempt})
+
+		connection, cerr := connectPool(ctx, config)
+		if cerr == nil {
+			configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Connected to the database", nil)
+
+			database := &Database{
+				Connection:    connection,
+				Replicas:      replicas,
+				configuration: configuration,
+				statements:    statements,
+				stop:          make(chan struct{}),
+			}
+
+			go database.watchReplicas()
+
+			return database, nil
+		}
 
-	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=%s",
+		err = cerr
+
+		if attempt >= retries {
+			closeReplicas()
+
+			return nil, errors.Wrapf(err, "Cannot connect to the database after %d attempts", attempt)
+		}
+
+		delay := Backoff(attempt, configuration.ConnectMinBackoff, configuration.ConnectMaxBackoff)
+
+		configuration.Logger.Log(ctx, pgx.LogLevelWarn, "Cannot connect to the database", map[string]interface{}{
+			"attempt": attempt,
+			"delay":   delay.String(),
+			"error":   err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			closeReplicas()
+
+			return nil, errors.WithSecondaryError(
+				errors.Wrap(err, "Cannot connect to the database before the context was done"), ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// connectionString creates the connection string of the database server at address
+// with the user, password, name and SSL mode of the configuration.
+func connectionString(configuration Configuration, address string) string {
+	return fmt.Sprintf("postgresql://%s:%s@%s/%s?sslmode=%s",
 		configuration.User,
 		configuration.Password,
-		configuration.Host,
-		configuration.Port,
+		address,
 		configuration.Name,
 		configuration.SSLMode,
 	)
+}
 
+// poolConfig creates the connection pool configuration of the database server of the connection string,
+// which prepares the statements of the configuration on every new connection.
+func poolConfig(configuration Configuration, dsn string,
+	statements *statementRegistry) (*pgxpool.Config, error) {
 	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, errors.Wrap(err, "Cannot parse database connection string")
@@ -57,36 +167,69 @@ func New(ctx context.Context, retries int, configuration Configuration) (*Databa
 
 	config.MinConns = int32(configuration.MinConns)
 	config.MaxConns = int32(configuration.MaxConns)
+
+	if configuration.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = configuration.MaxConnLifetime
+	}
+
+	if configuration.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = configuration.MaxConnIdleTime
+	}
+
+	if configuration.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = configuration.HealthCheckPeriod
+	}
+
 	config.ConnConfig.RuntimeParams["standard_conforming_strings"] = "on"
 	config.ConnConfig.RuntimeParams["application_name"] = configuration.AppName
 
 	config.ConnConfig.Logger = configuration.Logger
 	config.ConnConfig.LogLevel = configuration.LogLevel
 
-	timeoutExceeded := time.After(timeout)
-	for {
-		select {
-		case <-timeoutExceeded:
-			return nil, errors.New("Cannot connect to the database")
-		case <-delay.C:
-			configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Trying to connect to the database", nil)
-			connection, err := pgxpool.ConnectConfig(ctx, config)
-			if err == nil {
-				configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Connected to the database", nil)
-
-				return &Database{
-					Connection:    connection,
-					configuration: configuration,
-				}, nil
-			}
-		}
+	// Connections prepare the statements as soon as they are established, which fails while the schema is not
+	// migrated yet, so pools only connect when they are first used.
+	config.LazyConnect = true
+	config.AfterConnect = statements.connect
+
+	return config, nil
+}
+
+// connectPool creates the lazy pool of config, checking that its server is reachable beforehand
+// on a connection outside of the pool.
+func connectPool(ctx context.Context, config *pgxpool.Config) (*pgxpool.Pool, error) {
+	conn, err := pgx.ConnectConfig(ctx, config.ConnConfig)
+	if err != nil {
+		return nil, err // nolint
 	}
+
+	conn.Close(ctx) // nolint
+
+	return pgxpool.ConnectConfig(ctx, config) // nolint
+}
+
+// connect establishes a connection to the primary outside of its pool, which does not prepare the statements,
+// for the maintenance tasks that run before the schema is migrated. The caller must close it.
+func (d *Database) connect(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.ConnectConfig(ctx, d.Connection.Config().ConnConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot connect to the database")
+	}
+
+	return conn, nil
 }
 
-// Close shutdowns any connection to the database.
+// Close shutdowns any connection to the database. Further calls do nothing.
 func (d *Database) Close(ctx context.Context) error {
-	d.configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Closing database", nil)
-	d.Connection.Close()
+	d.closed.Do(func() {
+		d.configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Closing database", nil)
+		close(d.stop)
+
+		for _, replica := range d.Replicas {
+			replica.Connection.Close()
+		}
+
+		d.Connection.Close()
+	})
 
 	return nil
 }
@@ -104,51 +247,98 @@ func (d *Database) Health(ctx context.Context) error {
 	return nil
 }
 
-// Migrate runs database migrations up to the latest version.
-func (d *Database) Migrate(ctx context.Context) error {
-	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=%s&x-multi-statement=true",
-		d.configuration.User,
-		d.configuration.Password,
-		d.configuration.Host,
-		d.configuration.Port,
-		d.configuration.Name,
-		d.configuration.SSLMode,
-	)
+// Connection is a querier and execerer to interact with the database, which can
+// also pipeline batches of statements and bulk copy rows into a table.
+type Connection interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error)
+}
 
-	migrator, err := migrate.New("file://./migrations", dsn)
-	if err != nil {
-		return errors.Wrap(err, "Cannot begin migrator")
+type transactionKey struct{}
+
+// Transaction runs fn within a transaction stored in the context given to fn, so that every
+// Connection created with Join transparently participates in it. Nested calls use savepoints
+// and ignore the given options, as the outer transaction characteristics cannot be changed.
+func Transaction(ctx context.Context, db *pgxpool.Pool, fn func(ctx context.Context) error,
+	options ...TransactionOption) error {
+	var tx pgx.Tx
+	var err error
+
+	if parent, ok := ctx.Value(transactionKey{}).(pgx.Tx); ok {
+		tx, err = parent.Begin(ctx)
+		if err != nil {
+			return errors.Wrap(err, "Cannot begin savepoint")
+		}
+	} else {
+		tx, err = BeginTransaction(ctx, db, options...)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = migrator.Up()
-	switch err { // nolint
-	case nil:
-		d.configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Applying migrations", nil)
-	case migrate.ErrNoChange:
-		d.configuration.Logger.Log(ctx, pgx.LogLevelInfo, "No migrations to apply", nil)
-	default:
-		return errors.Wrap(err, "Error within a migration")
+	defer WatchTransaction(ctx, tx)()
+
+	err = fn(context.WithValue(ctx, transactionKey{}, tx))
+
+	return FinishTransaction(ctx, err, tx)
+}
+
+// Join returns a Connection that uses the transaction stored in the context of each call
+// by Transaction, or the pool if there is none.
+func Join(db *pgxpool.Pool) Connection {
+	return &joined{db: db}
+}
+
+type joined struct {
+	db *pgxpool.Pool
+}
+
+func (j *joined) connection(ctx context.Context) Connection {
+	if tx, ok := ctx.Value(transactionKey{}).(pgx.Tx); ok {
+		return tx
 	}
 
-	return nil
+	return j.db
 }
 
-// Connection is a querier and execerer to interact with the database.
-type Connection interface {
-	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
-	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+// Query satisfies the Connection interface.
+func (j *joined) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return j.connection(ctx).Query(ctx, sql, args...)
 }
 
-// BeginTransaction starts a database transaction.
-func BeginTransaction(ctx context.Context, db *pgxpool.Pool) (pgx.Tx, error) {
-	tx, err := db.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel:   pgx.Serializable,
-		AccessMode: pgx.ReadWrite,
-	})
+// Exec satisfies the Connection interface.
+func (j *joined) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return j.connection(ctx).Exec(ctx, sql, args...)
+}
+
+// SendBatch satisfies the Connection interface.
+func (j *joined) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return j.connection(ctx).SendBatch(ctx, b)
+}
+
+// CopyFrom satisfies the Connection interface.
+func (j *joined) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string,
+	rows pgx.CopyFromSource) (int64, error) {
+	return j.connection(ctx).CopyFrom(ctx, table, columns, rows)
+}
+
+// BeginTransaction starts a database transaction, serializable and read-write unless other options are given.
+func BeginTransaction(ctx context.Context, db *pgxpool.Pool, options ...TransactionOption) (pgx.Tx, error) {
+	opts := newTransactionOptions(options)
+
+	tx, err := db.BeginTx(ctx, opts.TxOptions)
 	if err != nil {
 		return nil, errors.Wrap(err, "Cannot begin transaction")
 	}
 
+	if err = opts.apply(ctx, tx); err != nil {
+		tx.Rollback(ctx) // nolint
+
+		return nil, err
+	}
+
 	return tx, nil
 }
 
@@ -0,0 +1,334 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgx/v4"
+)
+
+// DriftKinds enumerates the possible kinds of schema drift.
+var DriftKinds = struct {
+	TABLE      string
+	COLUMN     string
+	INDEX      string
+	CONSTRAINT string
+}{"table", "column", "index", "constraint"}
+
+// Drift describes a difference between the live schema and the schema produced by the migrations.
+// An empty Expected means the object only exists in the live schema, an empty Actual that it is missing.
+type Drift struct {
+	Table    string
+	Kind     string
+	Name     string
+	Expected string
+	Actual   string
+}
+
+// String describes the drift in a human readable way.
+func (d Drift) String() string {
+	switch {
+	case d.Expected == "":
+		return fmt.Sprintf("%s %s.%s is not in the migrations: %s", d.Kind, d.Table, d.Name, d.Actual)
+	case d.Actual == "":
+		return fmt.Sprintf("%s %s.%s is missing: %s", d.Kind, d.Table, d.Name, d.Expected)
+	default:
+		return fmt.Sprintf("%s %s.%s differs: expected %s, got %s", d.Kind, d.Table, d.Name, d.Expected, d.Actual)
+	}
+}
+
+// schema maps each table to its objects of each kind, described by their definition.
+type schema map[string]map[string]map[string]string
+
+// DetectDrift compares the live schema of the tables touched by the migrations with the schema produced by
+// applying the migrations up to the live version to a scratch database on the same server, which needs the
+// CREATEDB privilege. The scratch database of each version is built once and kept for the following checks.
+// No drift is reported while the database is ahead of the known migrations, as during rollouts.
+func (d *Database) DetectDrift(ctx context.Context) ([]Drift, error) {
+	migrator, err := NewMigrator(d.configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	version, dirty, err := migrator.Version()
+	latest := uint(0)
+	if err == nil {
+		latest, err = migrator.Latest()
+	}
+
+	migrator.Close() // nolint
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dirty {
+		return nil, errors.Newf("Cannot detect schema drift of dirty database version %d", version)
+	}
+
+	if version == 0 {
+		return nil, nil
+	}
+
+	if version > latest {
+		d.configuration.Logger.Log(ctx, pgx.LogLevelWarn,
+			"Skipping schema drift detection, the database version is ahead of the known migrations",
+			map[string]interface{}{"version": version, "latest": latest})
+
+		return nil, nil
+	}
+
+	var expected schema
+
+	err = d.WithMigrationLock(ctx, func() error {
+		scratch, err := d.scratchDatabase(ctx, version)
+		if err != nil {
+			return err
+		}
+
+		expected, err = d.scratchSchema(ctx, scratch)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(expected))
+	for table := range expected {
+		tables = append(tables, table)
+	}
+
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(context.Background()) // nolint
+
+	actual, err := inspectSchema(ctx, conn, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	return compareSchemas(expected, actual), nil
+}
+
+// scratchDatabase returns the name of the scratch database migrated to version, creating it unless a complete one
+// is left by a previous check, and dropping the scratch databases of other versions. It must run holding the
+// migration lock, so that instances do not build or drop the scratch databases concurrently.
+func (d *Database) scratchDatabase(ctx context.Context, version uint) (string, error) {
+	prefix := d.configuration.Name + "_drift_"
+	name := fmt.Sprintf("%sv%d", prefix, version)
+
+	configuration := d.configuration
+	configuration.Name = name
+
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(context.Background()) // nolint
+
+	rows, err := conn.Query(ctx, `SELECT "datname" FROM pg_database WHERE LEFT("datname", LENGTH($1)) = $1;`, prefix)
+	if err != nil {
+		return "", errors.Wrap(err, "Cannot list scratch databases")
+	}
+
+	existing := []string{}
+
+	for rows.Next() {
+		var datname string
+		if err = rows.Scan(&datname); err != nil {
+			rows.Close()
+
+			return "", errors.Wrap(err, "Cannot list scratch databases")
+		}
+
+		existing = append(existing, datname)
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", errors.Wrap(err, "Cannot list scratch databases")
+	}
+
+	for _, datname := range existing {
+		if datname == name && scratchComplete(configuration, version) {
+			return name, nil
+		}
+
+		// Scratch databases still being inspected by instances of another version cannot be dropped yet.
+		conn.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s;", pgx.Identifier{datname}.Sanitize())) // nolint
+	}
+
+	_, err = conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s;", pgx.Identifier{name}.Sanitize()))
+	if err != nil {
+		return "", errors.Wrap(err, "Cannot create scratch database")
+	}
+
+	migrator, err := NewMigrator(configuration)
+	if err != nil {
+		return "", err
+	}
+	defer migrator.Close() // nolint
+
+	if err = migrator.Goto(ctx, version); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// scratchComplete checks whether the scratch database was fully migrated to version by a previous check.
+func scratchComplete(configuration Configuration, version uint) bool {
+	migrator, err := NewMigrator(configuration)
+	if err != nil {
+		return false
+	}
+	defer migrator.Close() // nolint
+
+	current, dirty, err := migrator.Version()
+
+	return err == nil && !dirty && current == version
+}
+
+// scratchSchema inspects every table created by the migrations in the scratch database.
+func (d *Database) scratchSchema(ctx context.Context, name string) (schema, error) {
+	configuration := d.configuration
+	configuration.Name = name
+
+	conn, err := pgx.Connect(ctx, fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=%s",
+		configuration.User,
+		configuration.Password,
+		configuration.Host,
+		configuration.Port,
+		configuration.Name,
+		configuration.SSLMode,
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot connect to scratch database")
+	}
+	defer conn.Close(context.Background()) // nolint
+
+	rows, err := conn.Query(ctx, `SELECT "table_name" FROM information_schema.tables
+								  WHERE "table_schema" = 'public' AND "table_type" = 'BASE TABLE'
+								  AND "table_name" <> 'schema_migrations';`)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list scratch tables")
+	}
+
+	tables := []string{}
+
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			rows.Close()
+
+			return nil, errors.Wrap(err, "Cannot list scratch tables")
+		}
+
+		tables = append(tables, table)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Cannot list scratch tables")
+	}
+
+	return inspectSchema(ctx, conn, tables)
+}
+
+// inspectSchema describes the columns, indexes and constraints of the given public tables.
+func inspectSchema(ctx context.Context, cn Connection, tables []string) (schema, error) {
+	queries := map[string]string{
+		DriftKinds.COLUMN: `SELECT "table_name", "column_name",
+							concat_ws(' ', "data_type",
+									  '(' || "character_maximum_length" || ')',
+									  CASE WHEN "is_nullable" = 'NO' THEN 'NOT NULL' ELSE 'NULL' END,
+									  'DEFAULT ' || "column_default")
+							FROM information_schema.columns
+							WHERE "table_schema" = 'public' AND "table_name" = ANY($1);`,
+		DriftKinds.INDEX: `SELECT "tablename", "indexname", "indexdef" FROM pg_indexes
+						   WHERE "schemaname" = 'public' AND "tablename" = ANY($1);`,
+		DriftKinds.CONSTRAINT: `SELECT "rel"."relname", "con"."conname", pg_get_constraintdef("con"."oid")
+								FROM pg_constraint "con"
+								JOIN pg_class "rel" ON "rel"."oid" = "con"."conrelid"
+								JOIN pg_namespace "nsp" ON "nsp"."oid" = "rel"."relnamespace"
+								WHERE "nsp"."nspname" = 'public' AND "rel"."relname" = ANY($1);`,
+	}
+
+	result := schema{}
+	for _, table := range tables {
+		result[table] = map[string]map[string]string{}
+	}
+
+	for kind, query := range queries {
+		rows, err := cn.Query(ctx, query, tables)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot inspect %s schema", kind)
+		}
+
+		for rows.Next() {
+			var table, name, definition string
+			if err = rows.Scan(&table, &name, &definition); err != nil {
+				rows.Close()
+
+				return nil, errors.Wrapf(err, "Cannot inspect %s schema", kind)
+			}
+
+			if result[table][kind] == nil {
+				result[table][kind] = map[string]string{}
+			}
+
+			result[table][kind][name] = strings.Join(strings.Fields(definition), " ")
+		}
+
+		if err = rows.Err(); err != nil {
+			return nil, errors.Wrapf(err, "Cannot inspect %s schema", kind)
+		}
+	}
+
+	return result, nil
+}
+
+// compareSchemas lists the differences of the actual schema from the expected one, sorted by table.
+func compareSchemas(expected schema, actual schema) []Drift {
+	drifts := []Drift{}
+
+	for table, kinds := range expected {
+		if len(actual[table]) == 0 {
+			drifts = append(drifts, Drift{Table: table, Kind: DriftKinds.TABLE, Name: table, Expected: "table"})
+
+			continue
+		}
+
+		for _, kind := range []string{DriftKinds.COLUMN, DriftKinds.INDEX, DriftKinds.CONSTRAINT} {
+			for name, definition := range kinds[kind] {
+				if other := actual[table][kind][name]; other != definition {
+					drifts = append(drifts, Drift{Table: table, Kind: kind, Name: name, Expected: definition, Actual: other})
+				}
+			}
+
+			for name, definition := range actual[table][kind] {
+				if _, ok := kinds[kind][name]; !ok {
+					drifts = append(drifts, Drift{Table: table, Kind: kind, Name: name, Actual: definition})
+				}
+			}
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Table != drifts[j].Table {
+			return drifts[i].Table < drifts[j].Table
+		}
+
+		if drifts[i].Kind != drifts[j].Kind {
+			return drifts[i].Kind < drifts[j].Kind
+		}
+
+		return drifts[i].Name < drifts[j].Name
+	})
+
+	return drifts
+}
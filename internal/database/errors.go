@@ -1,34 +1,71 @@
 package database
 
 import (
-	"regexp"
+	"fmt"
 
 	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v4"
 )
 
-var codeExtractor = regexp.MustCompile(`\(SQLSTATE (.*)\)`)
-
 var (
-	ErrNoRows             = errors.New("No rows in result set")
-	ErrIntegrityViolation = errors.New("Integrity constraint violation")
+	ErrNoRows               = errors.New("No rows in result set")
+	ErrIntegrityViolation   = errors.New("Integrity constraint violation")
+	ErrUniqueViolation      = errors.New("Unique constraint violation")
+	ErrForeignKeyViolation  = errors.New("Foreign key constraint violation")
+	ErrNotNullViolation     = errors.New("Not null constraint violation")
+	ErrCheckViolation       = errors.New("Check constraint violation")
+	ErrExclusionViolation   = errors.New("Exclusion constraint violation")
+	ErrRestrictionViolation = errors.New("Restrict constraint violation")
 )
 
+// ConstraintError describes an integrity constraint violation.
+// It matches both its specific violation sentinel and ErrIntegrityViolation.
+type ConstraintError struct {
+	Violation  error
+	Constraint string
+	Table      string
+	Column     string
+	Err        *pgconn.PgError
+}
+
+// Error satisfies the standard error interface.
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s on %s.%s (%s): %s", e.Violation, e.Table, e.Column, e.Constraint, e.Err.Message)
+}
+
+// Unwrap satisfies the standard error interface.
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Is satisfies the standard error interface.
+func (e *ConstraintError) Is(reference error) bool {
+	return reference == e.Violation || reference == ErrIntegrityViolation // nolint
+}
+
 // Error transforms error into a database layer error.
 func Error(err error) error {
 	if err == nil {
 		return nil
 	}
 
-	if code := codeExtractor.FindStringSubmatch(err.Error()); len(code) == 2 { // nolint
-		if cErr := codeError(code[1]); cErr != nil {
-			return cErr
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if violation := codeError(pgErr.Code); violation != nil {
+			return &ConstraintError{
+				Violation:  violation,
+				Constraint: pgErr.ConstraintName,
+				Table:      pgErr.TableName,
+				Column:     pgErr.ColumnName,
+				Err:        pgErr,
+			}
 		}
 	}
 
-	if cErr := symmetricError(err); cErr != nil {
-		return cErr
+	if noRows(err) {
+		return errors.WithSecondaryError(ErrNoRows, err)
 	}
 
 	return err
@@ -37,21 +74,27 @@ func Error(err error) error {
 // nolint
 func codeError(code string) error {
 	switch code {
-	case pgerrcode.IntegrityConstraintViolation, pgerrcode.RestrictViolation, pgerrcode.NotNullViolation,
-		pgerrcode.ForeignKeyViolation, pgerrcode.UniqueViolation, pgerrcode.CheckViolation,
-		pgerrcode.ExclusionViolation:
+	case pgerrcode.UniqueViolation:
+		return ErrUniqueViolation
+	case pgerrcode.ForeignKeyViolation:
+		return ErrForeignKeyViolation
+	case pgerrcode.NotNullViolation:
+		return ErrNotNullViolation
+	case pgerrcode.CheckViolation:
+		return ErrCheckViolation
+	case pgerrcode.ExclusionViolation:
+		return ErrExclusionViolation
+	case pgerrcode.RestrictViolation:
+		return ErrRestrictionViolation
+	case pgerrcode.IntegrityConstraintViolation:
 		return ErrIntegrityViolation
 	default:
 		return nil
 	}
 }
 
-// nolint
-func symmetricError(err error) error {
-	switch err.Error() {
-	case pgx.ErrNoRows.Error():
-		return ErrNoRows
-	default:
-		return nil
-	}
+// noRows checks whether err means that no rows were found. pgxutil does not wrap
+// pgx.ErrNoRows but returns its own unexported error with the same message.
+func noRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows) || err.Error() == pgx.ErrNoRows.Error()
 }
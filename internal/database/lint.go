@@ -0,0 +1,228 @@
+package database
+
+import (
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// LintRules enumerates the possible migration lint rules.
+var LintRules = struct {
+	MISSING_DOWN             string // nolint
+	DROP_WITHOUT_DEPRECATION string // nolint
+	NOT_NULL_WITHOUT_DEFAULT string // nolint
+	NON_CONCURRENT_INDEX     string // nolint
+}{"missing-down", "drop-without-deprecation", "not-null-without-default", "non-concurrent-index"}
+
+// LintIgnoreDirective silences the given rule for the statement it is written in, e.g. -- lint:ignore missing-down.
+const LintIgnoreDirective = "-- lint:ignore"
+
+// LintIssue describes a risky operation found in a migration.
+type LintIssue struct {
+	File      string
+	Rule      string
+	Statement string
+	Message   string
+}
+
+var (
+	lintMigrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	lintComment       = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	lintSpaces        = regexp.MustCompile(`\s+`)
+	lintCreateTable   = regexp.MustCompile(`^create (?:unlogged |temporary |temp )?table (?:if not exists )?([\w.]+)`)
+	lintDropTable     = regexp.MustCompile(`^drop table (?:if exists )?(.+?)(?: cascade| restrict)?;?$`)
+	lintAlterTable    = regexp.MustCompile(`^alter table (?:if exists )?(?:only )?([\w.]+) (.+?);?$`)
+	lintDropColumn    = regexp.MustCompile(`^drop (?:column )?(?:if exists )?(\w+)`)
+	lintAddColumn     = regexp.MustCompile(`^add (?:column )?(?:if not exists )?(\w+) (.+)$`)
+	lintCreateIndex   = regexp.MustCompile(
+		`^create (?:unique )?index (concurrently )?(?:if not exists )?(?:\w+ )?on (?:only )?([\w.]+)`)
+	lintDeprecation = regexp.MustCompile(`^comment on (table|column) ([\w.]+) is '\s*deprecated`)
+)
+
+// LintMigrations checks the migrations of fsys for operations that may lose data or lock tables in production,
+// in version order so that tables created and deprecated by previous migrations are taken into account.
+func LintMigrations(fsys fs.FS) ([]LintIssue, error) { // nolint
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read migrations")
+	}
+
+	ups := map[string]string{}
+	downs := map[string]bool{}
+
+	for _, entry := range entries {
+		match := lintMigrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		if match[3] == "up" {
+			ups[match[1]+"_"+match[2]] = entry.Name()
+		} else {
+			downs[match[1]+"_"+match[2]] = true
+		}
+	}
+
+	migrations := make([]string, 0, len(ups))
+	for migration := range ups {
+		migrations = append(migrations, migration)
+	}
+
+	sort.Strings(migrations)
+
+	issues := []LintIssue{}
+	deprecated := map[string]bool{}
+
+	for _, migration := range migrations {
+		file := ups[migration]
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot read migration %s", file)
+		}
+
+		if !downs[migration] && !strings.Contains(string(content), LintIgnoreDirective+" "+LintRules.MISSING_DOWN) {
+			issues = append(issues, LintIssue{
+				File:    file,
+				Rule:    LintRules.MISSING_DOWN,
+				Message: "Migration cannot be reverted as it has no .down.sql counterpart",
+			})
+		}
+
+		created := map[string]bool{}
+		deprecating := []string{}
+
+		for _, statement := range splitStatements(string(content)) {
+			report := func(rule string, message string) {
+				if strings.Contains(statement, LintIgnoreDirective+" "+rule) {
+					return
+				}
+
+				issues = append(issues, LintIssue{
+					File:      file,
+					Rule:      rule,
+					Statement: summarize(statement),
+					Message:   message,
+				})
+			}
+
+			sql := normalize(statement)
+
+			if match := lintCreateTable.FindStringSubmatch(sql); match != nil {
+				created[unqualify(match[1])] = true
+			}
+
+			if match := lintDeprecation.FindStringSubmatch(sql); match != nil {
+				deprecating = append(deprecating, unqualify(match[2]))
+			}
+
+			if match := lintDropTable.FindStringSubmatch(sql); match != nil {
+				for _, table := range strings.Split(match[1], ",") {
+					table = unqualify(strings.TrimSpace(table))
+					if !deprecated[table] && !created[table] {
+						report(LintRules.DROP_WITHOUT_DEPRECATION,
+							"Table "+table+" is dropped without being deprecated by a previous migration")
+					}
+				}
+			}
+
+			if match := lintCreateIndex.FindStringSubmatch(sql); match != nil {
+				if table := unqualify(match[2]); match[1] == "" && !created[table] {
+					report(LintRules.NON_CONCURRENT_INDEX,
+						"Index on existing table "+table+" is not created CONCURRENTLY, locking the table for writes")
+				}
+			}
+
+			match := lintAlterTable.FindStringSubmatch(sql)
+			if match == nil {
+				continue
+			}
+
+			table := unqualify(match[1])
+
+			for _, action := range splitTopLevel(match[2]) {
+				if m := lintDropColumn.FindStringSubmatch(action); m != nil && m[1] != "constraint" {
+					if column := table + "." + m[1]; !deprecated[column] && !deprecated[table] && !created[table] {
+						report(LintRules.DROP_WITHOUT_DEPRECATION,
+							"Column "+column+" is dropped without being deprecated by a previous migration")
+					}
+				}
+
+				if m := lintAddColumn.FindStringSubmatch(action); m != nil && !constraintKeyword(m[1]) {
+					definition := " " + m[2] + " "
+					if strings.Contains(definition, " not null ") && !strings.Contains(definition, " default ") &&
+						!created[table] {
+						report(LintRules.NOT_NULL_WITHOUT_DEFAULT,
+							"Column "+table+"."+m[1]+" is added NOT NULL without a DEFAULT, failing on existing rows")
+					}
+				}
+			}
+		}
+
+		// Deprecations only allow drops in the following migrations, once the code stopped using them.
+		for _, name := range deprecating {
+			deprecated[name] = true
+		}
+	}
+
+	return issues, nil
+}
+
+// normalize strips the comments, quotes and redundant whitespace of a statement and lowercases it.
+func normalize(statement string) string {
+	statement = lintComment.ReplaceAllString(statement, " ")
+	statement = strings.ReplaceAll(statement, `"`, "")
+	statement = lintSpaces.ReplaceAllString(statement, " ")
+
+	return strings.ToLower(strings.TrimSpace(statement))
+}
+
+// summarize returns the first line of a statement without comments.
+func summarize(statement string) string {
+	for _, line := range strings.Split(statement, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return line
+		}
+	}
+
+	return ""
+}
+
+func unqualify(name string) string {
+	return strings.TrimPrefix(name, "public.")
+}
+
+func constraintKeyword(word string) bool {
+	switch word {
+	case "constraint", "primary", "unique", "foreign", "check", "exclude":
+		return true
+	default:
+		return false
+	}
+}
+
+// splitTopLevel splits the actions of an ALTER TABLE on the commas that are not within parentheses.
+func splitTopLevel(actions string) []string {
+	parts := []string{}
+	depth := 0
+	start := 0
+
+	for i, c := range actions {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(actions[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, strings.TrimSpace(actions[start:]))
+}
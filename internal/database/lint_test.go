@@ -0,0 +1,155 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLintMigrations(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "missing down",
+			files: map[string]string{
+				"0001_a.up.sql": `CREATE TABLE "a" ("id" INT);`,
+			},
+			want: []string{LintRules.MISSING_DOWN},
+		},
+		{
+			name: "present down",
+			files: map[string]string{
+				"0001_a.up.sql":   `CREATE TABLE "a" ("id" INT);`,
+				"0001_a.down.sql": `DROP TABLE "a";`,
+			},
+			want: []string{},
+		},
+		{
+			name: "ignored missing down",
+			files: map[string]string{
+				"0001_a.up.sql": "-- lint:ignore missing-down\nCREATE TABLE \"a\" (\"id\" INT);",
+			},
+			want: []string{},
+		},
+		{
+			name: "drop table without deprecation",
+			files: map[string]string{
+				"0001_a.up.sql":   `CREATE TABLE "a" ("id" INT);`,
+				"0001_a.down.sql": `DROP TABLE "a";`,
+				"0002_b.up.sql":   `DROP TABLE "a";`,
+				"0002_b.down.sql": `CREATE TABLE "a" ("id" INT);`,
+			},
+			want: []string{LintRules.DROP_WITHOUT_DEPRECATION},
+		},
+		{
+			name: "drop table deprecated by a previous migration",
+			files: map[string]string{
+				"0001_a.up.sql":   `CREATE TABLE "a" ("id" INT);`,
+				"0001_a.down.sql": `DROP TABLE "a";`,
+				"0002_b.up.sql":   `COMMENT ON TABLE "a" IS 'Deprecated';`,
+				"0002_b.down.sql": `COMMENT ON TABLE "a" IS NULL;`,
+				"0003_c.up.sql":   `DROP TABLE "a";`,
+				"0003_c.down.sql": `CREATE TABLE "a" ("id" INT);`,
+			},
+			want: []string{},
+		},
+		{
+			name: "drop column deprecated in the same migration",
+			files: map[string]string{
+				"0001_a.up.sql":   `CREATE TABLE "a" ("id" INT, "b" INT);`,
+				"0001_a.down.sql": `DROP TABLE "a";`,
+				"0002_b.up.sql":   "COMMENT ON COLUMN \"a\".\"b\" IS 'Deprecated';\nALTER TABLE \"a\" DROP COLUMN \"b\";",
+				"0002_b.down.sql": `ALTER TABLE "a" ADD COLUMN "b" INT;`,
+			},
+			want: []string{LintRules.DROP_WITHOUT_DEPRECATION},
+		},
+		{
+			name: "drop column deprecated by a previous migration",
+			files: map[string]string{
+				"0001_a.up.sql":   `CREATE TABLE "a" ("id" INT, "b" INT);`,
+				"0001_a.down.sql": `DROP TABLE "a";`,
+				"0002_b.up.sql":   `COMMENT ON COLUMN "a"."b" IS 'Deprecated';`,
+				"0002_b.down.sql": `COMMENT ON COLUMN "a"."b" IS NULL;`,
+				"0003_c.up.sql":   `ALTER TABLE "a" DROP COLUMN "b";`,
+				"0003_c.down.sql": `ALTER TABLE "a" ADD COLUMN "b" INT;`,
+			},
+			want: []string{},
+		},
+		{
+			name: "ignored drop without deprecation",
+			files: map[string]string{
+				"0001_a.up.sql":   "ALTER TABLE \"a\" DROP COLUMN \"b\"; -- lint:ignore drop-without-deprecation",
+				"0001_a.down.sql": `ALTER TABLE "a" ADD COLUMN "b" INT;`,
+			},
+			want: []string{},
+		},
+		{
+			name: "not null column without default",
+			files: map[string]string{
+				"0001_a.up.sql":   `ALTER TABLE "a" ADD COLUMN "b" INT NOT NULL;`,
+				"0001_a.down.sql": `ALTER TABLE "a" DROP COLUMN "b"; -- lint:ignore drop-without-deprecation`,
+			},
+			want: []string{LintRules.NOT_NULL_WITHOUT_DEFAULT},
+		},
+		{
+			name: "not null column with default",
+			files: map[string]string{
+				"0001_a.up.sql":   `ALTER TABLE "a" ADD COLUMN "b" INT NOT NULL DEFAULT 1;`,
+				"0001_a.down.sql": `ALTER TABLE "a" DROP COLUMN "b"; -- lint:ignore drop-without-deprecation`,
+			},
+			want: []string{},
+		},
+		{
+			name: "non concurrent index on existing table",
+			files: map[string]string{
+				"0001_a.up.sql":   `CREATE INDEX "a_b_idx" ON "a" ("b");`,
+				"0001_a.down.sql": `DROP INDEX "a_b_idx";`,
+			},
+			want: []string{LintRules.NON_CONCURRENT_INDEX},
+		},
+		{
+			name: "concurrent index on existing table",
+			files: map[string]string{
+				"0001_a.up.sql":   "-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY \"a_b_idx\" ON \"a\" (\"b\");",
+				"0001_a.down.sql": "-- migrate:no-transaction\nDROP INDEX CONCURRENTLY \"a_b_idx\";",
+			},
+			want: []string{},
+		},
+		{
+			name: "non concurrent index on created table",
+			files: map[string]string{
+				"0001_a.up.sql":   "CREATE TABLE \"a\" (\"b\" INT);\nCREATE INDEX \"a_b_idx\" ON \"a\" (\"b\");",
+				"0001_a.down.sql": `DROP TABLE "a";`,
+			},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for name, content := range tt.files {
+				fsys[name] = &fstest.MapFile{Data: []byte(content)}
+			}
+
+			issues, err := LintMigrations(fsys)
+			if err != nil {
+				t.Fatalf("LintMigrations() error = %v", err)
+			}
+
+			got := make([]string, 0, len(issues))
+			for _, issue := range issues {
+				got = append(got, issue.Rule)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintMigrations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
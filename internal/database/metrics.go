@@ -0,0 +1,62 @@
+package database
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PoolMetrics describes the statistics of a connection pool.
+type PoolMetrics struct {
+	Pool                    string
+	AcquiredConns           int32
+	IdleConns               int32
+	TotalConns              int32
+	MaxConns                int32
+	AcquireCount            int64
+	AcquireDuration         time.Duration
+	CanceledAcquireCount    int64
+	EmptyAcquireCount       int64
+	MaxLifetimeDestroyCount int64
+}
+
+// Metrics describes the statistics of the database.
+type Metrics struct {
+	Pools      []PoolMetrics
+	Statements []StatementMetrics
+	Timeouts   uint64
+}
+
+// Metrics returns the statistics of the primary and replica pools and of the registered statements.
+func (d *Database) Metrics() Metrics {
+	metrics := Metrics{
+		Pools:      make([]PoolMetrics, 0, len(d.Replicas)+1),
+		Statements: d.statements.metrics(),
+		Timeouts:   d.Timeouts(),
+	}
+
+	metrics.Pools = append(metrics.Pools, poolMetrics("primary", d.Connection))
+
+	for _, replica := range d.Replicas {
+		metrics.Pools = append(metrics.Pools, poolMetrics(replica.Address, replica.Connection))
+	}
+
+	return metrics
+}
+
+func poolMetrics(name string, pool *pgxpool.Pool) PoolMetrics {
+	stat := pool.Stat()
+
+	return PoolMetrics{
+		Pool:                    name,
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		TotalConns:              stat.TotalConns(),
+		MaxConns:                stat.MaxConns(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDuration:         stat.AcquireDuration(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+	}
+}
@@ -0,0 +1,353 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // nolint
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/neoxelox/zeus/migrations"
+)
+
+// MigrationsDirectory is the directory the migrations are created in.
+const MigrationsDirectory = "./migrations"
+
+// migrationLockKey is the key of the advisory lock held while migrating, so that only one instance migrates.
+const migrationLockKey = 0x7a657573
+
+// Migrate runs database migrations up to the latest version while holding the migration lock,
+// waiting up to MigrationLockTimeout for other instances to finish migrating.
+func (d *Database) Migrate(ctx context.Context) error {
+	return d.WithMigrationLock(ctx, func() error {
+		migrator, err := NewMigrator(d.configuration)
+		if err != nil {
+			return err
+		}
+		defer migrator.Close() // nolint
+
+		return migrator.Up(ctx, 0)
+	})
+}
+
+// VerifyMigrations checks that every migration is applied and that the last one did not fail,
+// without applying any. Versions newer than the known migrations are accepted during rollouts.
+func (d *Database) VerifyMigrations(ctx context.Context) error {
+	migrator, err := NewMigrator(d.configuration)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close() // nolint
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return errors.Newf("Database version %d is dirty, a migration failed", version)
+	}
+
+	latest, err := migrator.Latest()
+	if err != nil {
+		return err
+	}
+
+	if version < latest {
+		return errors.Newf("Database version %d is behind the latest migration %d", version, latest)
+	}
+
+	if version > latest {
+		d.configuration.Logger.Log(ctx, pgx.LogLevelWarn, "Database version is ahead of the known migrations",
+			map[string]interface{}{"version": version, "latest": latest})
+	}
+
+	return nil
+}
+
+// WithMigrationLock runs fn holding the migration advisory lock on a dedicated connection,
+// waiting up to MigrationLockTimeout for other instances to release it.
+func (d *Database) WithMigrationLock(ctx context.Context, fn func() error) error {
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background()) // nolint
+
+	lctx := ctx
+	if d.configuration.MigrationLockTimeout > 0 {
+		var cancel context.CancelFunc
+		lctx, cancel = context.WithTimeout(ctx, d.configuration.MigrationLockTimeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond) // nolint
+	defer ticker.Stop()
+
+	for waiting := false; ; waiting = true {
+		var locked bool
+
+		err = conn.QueryRow(lctx, "SELECT pg_try_advisory_lock($1);", migrationLockKey).Scan(&locked)
+		if err != nil {
+			return errors.Wrap(err, "Cannot acquire the migration lock")
+		}
+
+		if locked {
+			break
+		}
+
+		if !waiting {
+			d.configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Waiting for another instance to migrate",
+				map[string]interface{}{"timeout": d.configuration.MigrationLockTimeout.String()})
+		}
+
+		select {
+		case <-lctx.Done():
+			return errors.Wrap(lctx.Err(), "Cannot acquire the migration lock before the timeout")
+		case <-ticker.C:
+		}
+	}
+
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1);", migrationLockKey) // nolint
+
+	return fn()
+}
+
+// Migration describes a migration and whether it is applied to the database.
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Migrator applies the migrations to the database.
+type Migrator struct {
+	migrate       *migrate.Migrate
+	source        source.Driver
+	configuration Configuration
+}
+
+// NewMigrator creates a new Migrator instance for the database described by the configuration, reading
+// the migrations embedded in the binary unless an on-disk MigrationsDirectory is configured.
+func NewMigrator(configuration Configuration) (*Migrator, error) {
+	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=%s",
+		configuration.User,
+		configuration.Password,
+		configuration.Host,
+		configuration.Port,
+		configuration.Name,
+		configuration.SSLMode,
+	)
+
+	var src source.Driver
+	var err error
+
+	if configuration.MigrationsDirectory != "" {
+		src, err = source.Open("file://" + configuration.MigrationsDirectory)
+	} else {
+		src, err = httpfs.New(http.FS(migrations.FS), ".")
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot open migrations")
+	}
+
+	driver, err := (&postgres.Postgres{}).Open(dsn)
+	if err != nil {
+		src.Close() // nolint
+
+		return nil, errors.Wrap(err, "Cannot connect migrator to the database")
+	}
+
+	migrator, err := migrate.NewWithInstance("migrations", src, "postgres", &statementDriver{Driver: driver})
+	if err != nil {
+		src.Close()    // nolint
+		driver.Close() // nolint
+
+		return nil, errors.Wrap(err, "Cannot begin migrator")
+	}
+
+	return &Migrator{
+		migrate:       migrator,
+		source:        src,
+		configuration: configuration,
+	}, nil
+}
+
+// Close closes the source and database connections of the migrator.
+func (m *Migrator) Close() error {
+	serr, derr := m.migrate.Close()
+	if serr != nil {
+		return errors.Wrap(serr, "Cannot close migrations")
+	}
+
+	if derr != nil {
+		return errors.Wrap(derr, "Cannot close migrator database")
+	}
+
+	return nil
+}
+
+// Up applies the next n migrations, or every pending migration if n is 0.
+func (m *Migrator) Up(ctx context.Context, n int) error {
+	if n > 0 {
+		return m.run(ctx, "Applying migrations", func() error { return m.migrate.Steps(n) })
+	}
+
+	return m.run(ctx, "Applying migrations", m.migrate.Up)
+}
+
+// Down reverts the last n migrations, which must be positive.
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		return errors.Newf("Cannot revert %d migrations, use DownAll to revert every one", n)
+	}
+
+	return m.run(ctx, "Reverting migrations", func() error { return m.migrate.Steps(-n) })
+}
+
+// DownAll reverts every applied migration.
+func (m *Migrator) DownAll(ctx context.Context) error {
+	return m.run(ctx, "Reverting migrations", m.migrate.Down)
+}
+
+// Goto applies or reverts the migrations needed to reach the given version.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.run(ctx, "Migrating to version", func() error { return m.migrate.Migrate(version) })
+}
+
+// Force sets the version of the database without running any migration and clears its dirty state.
+func (m *Migrator) Force(version int) error {
+	if err := m.migrate.Force(version); err != nil {
+		return errors.Wrapf(err, "Cannot force version %d", version)
+	}
+
+	return nil
+}
+
+// Version returns the version of the database and whether its last migration failed.
+// A database without any migration applied is at version 0.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, errors.Wrap(err, "Cannot get database version")
+	}
+
+	return version, dirty, nil
+}
+
+// Latest returns the version of the last known migration.
+func (m *Migrator) Latest() (uint, error) {
+	list, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(list) == 0 {
+		return 0, nil
+	}
+
+	return list[len(list)-1].Version, nil
+}
+
+// Status lists every migration, marking the ones applied to the database.
+func (m *Migrator) Status() ([]Migration, error) {
+	current, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []Migration{}
+
+	version, err := m.source.First()
+	for err == nil {
+		name := ""
+		if r, identifier, rerr := m.source.ReadUp(version); rerr == nil {
+			r.Close()
+			name = identifier
+		}
+
+		list = append(list, Migration{Version: version, Name: name, Applied: version <= current})
+
+		version, err = m.source.Next(version)
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrap(err, "Cannot list migrations")
+	}
+
+	return list, nil
+}
+
+func (m *Migrator) run(ctx context.Context, message string, fn func() error) error {
+	err := fn()
+	switch { // nolint
+	case err == nil:
+		m.configuration.Logger.Log(ctx, pgx.LogLevelInfo, message, nil)
+	case errors.Is(err, migrate.ErrNoChange):
+		m.configuration.Logger.Log(ctx, pgx.LogLevelInfo, "No migrations to apply", nil)
+	default:
+		return errors.Wrap(err, "Error within a migration")
+	}
+
+	return nil
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_.+\.(up|down)\.sql$`)
+
+// CreateMigration creates empty up and down files for a new migration named name
+// in the given directory, numbered after the last one, returning their paths.
+func CreateMigration(directory string, name string) ([]string, error) {
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return nil, errors.Newf("Migration name %q must be lowercase snake case", name)
+	}
+
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read migrations directory")
+	}
+
+	last := 0
+
+	for _, file := range files {
+		if match := migrationFile.FindStringSubmatch(file.Name()); match != nil {
+			var version int
+			fmt.Sscanf(match[1], "%d", &version) // nolint
+
+			if version > last {
+				last = version
+			}
+		}
+	}
+
+	paths := make([]string, 0, 2) // nolint
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(directory, fmt.Sprintf("%04d_%s.%s.sql", last+1, name, direction))
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644) // nolint
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot create migration file %s", path)
+		}
+		file.Close()
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
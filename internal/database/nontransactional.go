@@ -0,0 +1,183 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	migrationdatabase "github.com/golang-migrate/migrate/v4/database"
+)
+
+// NoTransactionDirective marks a migration file whose statements must run one by one outside of a
+// transaction, such as CREATE INDEX CONCURRENTLY. It must appear before the first statement of the file,
+// whose statements should be idempotent so that it can be rerun after a partial failure. A failed
+// CREATE INDEX CONCURRENTLY leaves an INVALID index behind, which IF NOT EXISTS would then skip,
+// so such indexes are dropped with DROP INDEX CONCURRENTLY IF EXISTS right before being created.
+const NoTransactionDirective = "-- migrate:no-transaction"
+
+// statementDriver runs the migrations marked with NoTransactionDirective statement by statement.
+// Other migrations are sent as a single query, which postgres runs in an implicit transaction.
+type statementDriver struct {
+	migrationdatabase.Driver
+}
+
+// Run satisfies the database.Driver interface.
+func (d *statementDriver) Run(migration io.Reader) error {
+	content, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return err // nolint
+	}
+
+	if !noTransaction(content) {
+		return d.Driver.Run(bytes.NewReader(content))
+	}
+
+	statements := splitStatements(string(content))
+
+	for i, statement := range statements {
+		if err := d.Driver.Run(strings.NewReader(statement)); err != nil {
+			return errors.Wrapf(err,
+				"Non-transactional migration failed at statement %d of %d, the previous %d statements were applied "+
+					"and not rolled back: the database is left dirty until the migration is completed or reverted "+
+					"by hand and its version forced", i+1, len(statements), i)
+		}
+	}
+
+	return nil
+}
+
+// noTransaction checks whether the migration has the NoTransactionDirective before its first statement.
+func noTransaction(content []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == NoTransactionDirective:
+			return true
+		case line == "", strings.HasPrefix(line, "--"):
+			continue
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// splitStatements splits SQL into its statements on semicolons that are not within
+// quotes, dollar-quoted strings or comments, discarding the empty ones. Comments
+// following a semicolon on the same line are kept with the statement they follow.
+func splitStatements(sql string) []string { // nolint
+	statements := []string{}
+	current := strings.Builder{}
+
+	flush := func() {
+		statement := strings.TrimSpace(current.String())
+		if statement != "" && !onlyComments(statement) {
+			statements = append(statements, statement)
+		}
+
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+
+			current.WriteString(sql[i : i+end])
+			i += end - 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 4 // nolint
+			}
+
+			current.WriteString(sql[i : i+end+4])
+			i += end + 3 // nolint
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				end = len(sql) - i - 2 // nolint
+			}
+
+			current.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case c == '$':
+			tag := dollarTag(sql[i:])
+			if tag == "" {
+				current.WriteByte(c)
+
+				continue
+			}
+
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				end = len(sql) - i - 2*len(tag)
+			}
+
+			current.WriteString(sql[i : i+end+2*len(tag)])
+			i += end + 2*len(tag) - 1
+		case c == ';':
+			current.WriteByte(c)
+
+			// A comment on the same line belongs to the statement it follows.
+			rest := sql[i+1:]
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				rest = rest[:end]
+			}
+
+			if trimmed := strings.TrimSpace(rest); trimmed == "" || strings.HasPrefix(trimmed, "--") {
+				current.WriteString(rest)
+				i += len(rest)
+			}
+
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	flush()
+
+	return statements
+}
+
+// dollarTag returns the dollar quote tag, such as $$ or $body$, at the start of sql, if any.
+func dollarTag(sql string) string {
+	for i := 1; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == '$':
+			return sql[:i+1]
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', i > 1 && c >= '0' && c <= '9':
+			continue
+		default:
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func onlyComments(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+
+	return true
+}
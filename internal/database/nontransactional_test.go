@@ -0,0 +1,100 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "empty",
+			sql:  "",
+			want: []string{},
+		},
+		{
+			name: "only comments",
+			sql:  "-- migrate:no-transaction\n-- nothing\n",
+			want: []string{},
+		},
+		{
+			name: "single statement without semicolon",
+			sql:  "SELECT 1",
+			want: []string{"SELECT 1"},
+		},
+		{
+			name: "multiple statements",
+			sql:  "SELECT 1;\nSELECT 2;\n\nSELECT 3;",
+			want: []string{"SELECT 1;", "SELECT 2;", "SELECT 3;"},
+		},
+		{
+			name: "directive before the first statement",
+			sql:  "-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY \"a\" ON \"t\" (\"c\");",
+			want: []string{"-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY \"a\" ON \"t\" (\"c\");"},
+		},
+		{
+			name: "trailing comment on the same line",
+			sql:  "SELECT 1; -- first\nSELECT 2;",
+			want: []string{"SELECT 1; -- first", "SELECT 2;"},
+		},
+		{
+			name: "semicolon in single quotes",
+			sql:  "SELECT 'a;b';SELECT 2;",
+			want: []string{"SELECT 'a;b';", "SELECT 2;"},
+		},
+		{
+			name: "semicolon in double quotes",
+			sql:  `CREATE TABLE "a;b" ("c" INT);SELECT 2;`,
+			want: []string{`CREATE TABLE "a;b" ("c" INT);`, "SELECT 2;"},
+		},
+		{
+			name: "semicolon in line comment",
+			sql:  "SELECT 1 -- not; a split\n;SELECT 2;",
+			want: []string{"SELECT 1 -- not; a split\n;", "SELECT 2;"},
+		},
+		{
+			name: "semicolon in block comment",
+			sql:  "SELECT /* not; a split */ 1;SELECT 2;",
+			want: []string{"SELECT /* not; a split */ 1;", "SELECT 2;"},
+		},
+		{
+			name: "semicolon in dollar quotes",
+			sql:  "DO $$ BEGIN PERFORM 1; END $$;SELECT 2;",
+			want: []string{"DO $$ BEGIN PERFORM 1; END $$;", "SELECT 2;"},
+		},
+		{
+			name: "semicolon in tagged dollar quotes",
+			sql:  "DO $body$ BEGIN PERFORM '$$;'; END $body$;SELECT 2;",
+			want: []string{"DO $body$ BEGIN PERFORM '$$;'; END $body$;", "SELECT 2;"},
+		},
+		{
+			name: "positional parameter is not a dollar quote",
+			sql:  "PREPARE p AS SELECT $1;SELECT 2;",
+			want: []string{"PREPARE p AS SELECT $1;", "SELECT 2;"},
+		},
+		{
+			name: "unterminated quote",
+			sql:  "SELECT 'a;b",
+			want: []string{"SELECT 'a;b"},
+		},
+		{
+			name: "unterminated block comment",
+			sql:  "SELECT 1; /* a;b",
+			want: []string{"SELECT 1;", "/* a;b"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
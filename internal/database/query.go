@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+)
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout returns a context whose queries time out after timeout instead of
+// the default StatementTimeout. A zero timeout disables the timeout of its queries.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// Timeouts returns the number of queries that exceeded their timeout.
+func (d *Database) Timeouts() uint64 {
+	return atomic.LoadUint64(&d.timeouts)
+}
+
+// instrument wraps a Connection applying query timeouts and logging slow queries.
+func (d *Database) instrument(cn Connection) Connection {
+	return &instrumented{database: d, cn: cn}
+}
+
+type instrumented struct {
+	database *Database
+	cn       Connection
+}
+
+// Query satisfies the Connection interface.
+func (i *instrumented) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	qctx, finish := i.database.begin(ctx, sql, args)
+
+	rows, err := i.cn.Query(qctx, sql, args...)
+	if err != nil {
+		finish(err)
+
+		return rows, err // nolint
+	}
+
+	return &instrumentedRows{Rows: rows, finish: finish}, nil
+}
+
+// Exec satisfies the Connection interface.
+func (i *instrumented) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	qctx, finish := i.database.begin(ctx, sql, args)
+
+	tag, err := i.cn.Exec(qctx, sql, args...)
+	finish(err)
+
+	return tag, err // nolint
+}
+
+// SendBatch satisfies the Connection interface.
+func (i *instrumented) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	qctx, finish := i.database.begin(ctx, fmt.Sprintf("BATCH (%d statements)", b.Len()), nil)
+
+	return &instrumentedBatchResults{BatchResults: i.cn.SendBatch(qctx, b), finish: finish}
+}
+
+// CopyFrom satisfies the Connection interface.
+func (i *instrumented) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string,
+	rows pgx.CopyFromSource) (int64, error) {
+	qctx, finish := i.database.begin(ctx, fmt.Sprintf("COPY %s", table.Sanitize()), nil)
+
+	count, err := i.cn.CopyFrom(qctx, table, columns, rows)
+	finish(err)
+
+	return count, err // nolint
+}
+
+// begin starts timing a query, returning its context with the timeout applied
+// and a function to be called once the query has finished.
+func (d *Database) begin(ctx context.Context, sql string, args []interface{}) (context.Context, func(error)) {
+	timeout := d.configuration.StatementTimeout
+	if override, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+		timeout = override
+	}
+
+	cancel := context.CancelFunc(func() {})
+	qctx := ctx
+
+	if timeout > 0 {
+		qctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	start := time.Now()
+	once := sync.Once{}
+
+	return qctx, func(err error) {
+		once.Do(func() {
+			duration := time.Since(start)
+			timedOut := errors.Is(qctx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+			cancel()
+
+			d.statements.observe(sql, duration, err)
+
+			var pgErr *pgconn.PgError
+			if timedOut || (errors.As(err, &pgErr) && pgErr.Code == pgerrcode.QueryCanceled) {
+				atomic.AddUint64(&d.timeouts, 1)
+				d.configuration.Logger.Log(ctx, pgx.LogLevelError, "Query timeout", d.queryData(sql, args, duration))
+
+				return
+			}
+
+			if d.configuration.SlowQueryThreshold > 0 && duration >= d.configuration.SlowQueryThreshold {
+				d.configuration.Logger.Log(ctx, pgx.LogLevelWarn, "Slow query", d.queryData(sql, args, duration))
+			}
+		})
+	}
+}
+
+// queryData builds the log fields of a query, naming it after its statement if registered and sanitizing
+// its arguments so that no user supplied text or binary data is disclosed in the logs.
+func (d *Database) queryData(sql string, args []interface{}, duration time.Duration) map[string]interface{} {
+	sanitized := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		switch value := arg.(type) {
+		case nil:
+			sanitized = append(sanitized, "NULL")
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			sanitized = append(sanitized, fmt.Sprint(value))
+		case time.Time:
+			sanitized = append(sanitized, value.Format(time.RFC3339Nano))
+		case string:
+			sanitized = append(sanitized, fmt.Sprintf("<string len=%d>", len(value)))
+		case []byte:
+			sanitized = append(sanitized, fmt.Sprintf("<bytes len=%d>", len(value)))
+		default:
+			sanitized = append(sanitized, fmt.Sprintf("<%T>", value))
+		}
+	}
+
+	data := map[string]interface{}{
+		"sql":      sql,
+		"args":     sanitized,
+		"duration": duration,
+	}
+
+	if statement, ok := d.statements.lookup(sql); ok {
+		data["statement"] = sql
+		data["sql"] = statement
+	}
+
+	return data
+}
+
+type instrumentedRows struct {
+	pgx.Rows
+	finish func(error)
+}
+
+// Next satisfies the pgx.Rows interface.
+func (r *instrumentedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+
+	r.finish(r.Rows.Err())
+
+	return false
+}
+
+// Close satisfies the pgx.Rows interface.
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	r.finish(r.Rows.Err())
+}
+
+type instrumentedBatchResults struct {
+	pgx.BatchResults
+	finish func(error)
+}
+
+// Close satisfies the pgx.BatchResults interface.
+func (r *instrumentedBatchResults) Close() error {
+	err := r.BatchResults.Close()
+	r.finish(err)
+
+	return err // nolint
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Replica describes a read-only replica of the database, identified by the address of its server.
+type Replica struct {
+	Address    string
+	Connection *pgxpool.Pool
+	healthy    int32
+}
+
+// Healthy checks whether the last health check of the replica succeeded.
+func (r *Replica) Healthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+// connectReplicas creates the replica pools lazily, so that unreachable replicas
+// do not prevent the application from starting and are just considered unhealthy.
+// Replicas given as a host:port address share the user, password, name and SSL mode of the primary,
+// while replicas given as a connection string, either URL or keyword/value, are used as they are.
+func connectReplicas(ctx context.Context, configuration Configuration,
+	statements *statementRegistry) ([]*Replica, error) {
+	replicas := make([]*Replica, 0, len(configuration.Replicas))
+
+	for _, replica := range configuration.Replicas {
+		if replica = strings.TrimSpace(replica); replica == "" {
+			continue
+		}
+
+		dsn := replica
+		if !strings.Contains(replica, "://") && !strings.Contains(replica, "=") {
+			dsn = connectionString(configuration, replica)
+		}
+
+		config, err := poolConfig(configuration, dsn, statements)
+		if err != nil {
+			return nil, errors.Wrap(err, "Cannot configure database replica")
+		}
+
+		// The address identifies the replica in logs and metrics without disclosing its credentials.
+		address := fmt.Sprintf("%s:%d", config.ConnConfig.Host, config.ConnConfig.Port)
+
+		connection, err := pgxpool.ConnectConfig(ctx, config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot connect to the database replica %s", address)
+		}
+
+		replicas = append(replicas, &Replica{
+			Address:    address,
+			Connection: connection,
+		})
+	}
+
+	return replicas, nil
+}
+
+// watchReplicas checks the health of the replicas periodically until the database is closed.
+func (d *Database) watchReplicas() {
+	if len(d.Replicas) == 0 || d.configuration.ReplicaCheckPeriod <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.configuration.ReplicaCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		d.checkReplicas()
+
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replicaLagQuery measures how far behind the primary the replica is, as the time since the last replayed
+// transaction, unless it has replayed everything it received, as the primary may just have no writes.
+const replicaLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp()), 0)
+END::FLOAT8;`
+
+// checkReplicas marks as unhealthy the replicas that are unreachable or lag behind more than MaxReplicaLag.
+func (d *Database) checkReplicas() {
+	for _, replica := range d.Replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), d.configuration.ReplicaCheckPeriod)
+
+		var lag float64
+
+		err := replica.Connection.QueryRow(ctx, replicaLagQuery).Scan(&lag)
+		if err == nil && d.configuration.MaxReplicaLag > 0 &&
+			time.Duration(lag*float64(time.Second)) > d.configuration.MaxReplicaLag {
+			err = errors.Newf("Replication lag of %.1fs exceeds the maximum of %s", lag, d.configuration.MaxReplicaLag)
+		}
+
+		cancel()
+
+		healthy := int32(0)
+		if err == nil {
+			healthy = 1
+		}
+
+		if previous := atomic.SwapInt32(&replica.healthy, healthy); previous != healthy {
+			data := map[string]interface{}{"replica": replica.Address}
+			if err != nil {
+				data["error"] = err.Error()
+			}
+
+			if healthy == 1 {
+				d.configuration.Logger.Log(ctx, pgx.LogLevelInfo, "Database replica is healthy", data)
+			} else {
+				d.configuration.Logger.Log(ctx, pgx.LogLevelWarn, "Database replica is unhealthy", data)
+			}
+		}
+	}
+}
+
+// replica returns the next healthy replica in round-robin order, or nil if there is none.
+func (d *Database) replica() *Replica {
+	count := len(d.Replicas)
+
+	for i := 0; i < count; i++ {
+		replica := d.Replicas[int(atomic.AddUint32(&d.next, 1))%count]
+		if replica.Healthy() {
+			return replica
+		}
+	}
+
+	return nil
+}
+
+type sessionKey struct{}
+
+type session struct {
+	lastWrite int64
+}
+
+// WithSession returns a context that tracks the writes made with it, so that reads
+// within ReadYourWritesWindow after a write are routed to the primary.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// Writer returns a Connection to the primary that joins the transaction in the context of each call,
+// recording the call as a write in the session of the context.
+func (d *Database) Writer() Connection {
+	return d.instrument(&writer{joined: joined{db: d.Connection}})
+}
+
+// Reader returns a Connection for read-only queries that joins the transaction in the context of each call,
+// or otherwise routes the call to a healthy replica, falling back to the primary.
+func (d *Database) Reader() Connection {
+	return d.instrument(&reader{database: d})
+}
+
+type writer struct {
+	joined
+}
+
+func (w *writer) mark(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		atomic.StoreInt64(&s.lastWrite, time.Now().UnixNano())
+	}
+}
+
+// Query satisfies the Connection interface.
+func (w *writer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	w.mark(ctx)
+
+	return w.joined.Query(ctx, sql, args...)
+}
+
+// Exec satisfies the Connection interface.
+func (w *writer) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	w.mark(ctx)
+
+	return w.joined.Exec(ctx, sql, args...)
+}
+
+// SendBatch satisfies the Connection interface.
+func (w *writer) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	w.mark(ctx)
+
+	return w.joined.SendBatch(ctx, b)
+}
+
+// CopyFrom satisfies the Connection interface.
+func (w *writer) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string,
+	rows pgx.CopyFromSource) (int64, error) {
+	w.mark(ctx)
+
+	return w.joined.CopyFrom(ctx, table, columns, rows)
+}
+
+type reader struct {
+	database *Database
+}
+
+func (r *reader) connection(ctx context.Context) Connection {
+	if tx, ok := ctx.Value(transactionKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		lastWrite := atomic.LoadInt64(&s.lastWrite)
+		if lastWrite != 0 && time.Since(time.Unix(0, lastWrite)) < r.database.configuration.ReadYourWritesWindow {
+			return r.database.Connection
+		}
+	}
+
+	if replica := r.database.replica(); replica != nil {
+		return replica.Connection
+	}
+
+	return r.database.Connection
+}
+
+// Query satisfies the Connection interface.
+func (r *reader) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return r.connection(ctx).Query(ctx, sql, args...)
+}
+
+// Exec satisfies the Connection interface.
+func (r *reader) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return r.connection(ctx).Exec(ctx, sql, args...)
+}
+
+// SendBatch satisfies the Connection interface. Batches may contain writes,
+// so they are never routed to a replica.
+func (r *reader) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return (&joined{db: r.database.Connection}).SendBatch(ctx, b)
+}
+
+// CopyFrom satisfies the Connection interface. Copies are writes,
+// so they are never routed to a replica.
+func (r *reader) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string,
+	rows pgx.CopyFromSource) (int64, error) {
+	return (&joined{db: r.database.Connection}).CopyFrom(ctx, table, columns, rows)
+}
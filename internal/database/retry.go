@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrRetriesExhausted transaction kept failing after all the allowed attempts.
+var ErrRetriesExhausted = errors.New("Transaction retries exhausted")
+
+// RetryError describes a transaction that failed with a retryable error in every attempt.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+// Error satisfies the standard error interface.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("Transaction retries exhausted after %d attempts: %s", e.Attempts, e.Err)
+}
+
+// Unwrap satisfies the standard error interface.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Is satisfies the standard error interface.
+func (e *RetryError) Is(reference error) bool {
+	return reference == ErrRetriesExhausted // nolint
+}
+
+// Transaction runs fn within a transaction like the package level Transaction, retrying it
+// as a whole with jittered exponential backoff when it fails due to a serialization failure
+// or a deadlock. Nested transactions are not retried, as the whole outer transaction is doomed.
+// As fn may run several times, it must not have effects outside of the database, such as HTTP calls,
+// unless the transaction is started with NoRetry.
+func (d *Database) Transaction(ctx context.Context, fn func(ctx context.Context) error,
+	options ...TransactionOption) error {
+	if _, ok := ctx.Value(transactionKey{}).(pgx.Tx); ok {
+		return Transaction(ctx, d.Connection, fn)
+	}
+
+	attempts := d.configuration.TransactionAttempts
+	if attempts < 1 || newTransactionOptions(options).noRetry {
+		attempts = 1
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = Transaction(ctx, d.Connection, fn, options...)
+		if !retryable(err) {
+			return err
+		}
+
+		if attempt < attempts {
+			d.configuration.Logger.Log(ctx, pgx.LogLevelWarn, "Retrying transaction", map[string]interface{}{
+				"attempt": attempt,
+				"error":   err.Error(),
+			})
+
+			select {
+			case <-ctx.Done():
+				return errors.Wrap(ctx.Err(), "Cannot retry transaction")
+			case <-time.After(Backoff(attempt, d.configuration.TransactionMinBackoff,
+				d.configuration.TransactionMaxBackoff)):
+			}
+		}
+	}
+
+	return &RetryError{Attempts: attempts, Err: err}
+}
+
+// retryable checks whether err is a serialization failure or a deadlock.
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected
+	}
+
+	return false
+}
+
+// Backoff returns the delay after the given attempt, doubling from min up to max with full jitter.
+func Backoff(attempt int, min time.Duration, max time.Duration) time.Duration {
+	delay := min
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1)) // nolint
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgx/v4"
+)
+
+// Statement describes a named SQL statement prepared on every connection of the database.
+// Queries whose SQL is the name of a statement of the configuration execute the prepared statement.
+type Statement struct {
+	Name string
+	SQL  string
+}
+
+// StatementMetrics describes the statistics of a registered statement.
+type StatementMetrics struct {
+	Name     string
+	Calls    uint64
+	Errors   uint64
+	Duration time.Duration
+}
+
+// Prepare checks that the statements of the configuration can be prepared once the schema is migrated,
+// establishing a connection of the primary if there is none, as connections failing to prepare them are discarded.
+func (d *Database) Prepare(ctx context.Context) error {
+	conn, err := d.Connection.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Cannot prepare the database statements")
+	}
+
+	conn.Release()
+
+	return nil
+}
+
+type statementStats struct {
+	calls    uint64
+	errors   uint64
+	duration int64
+}
+
+// statementRegistry holds the statements of the database, which are fixed once it is created
+// so that every connection prepares all of them as soon as it is established.
+type statementRegistry struct {
+	sql   map[string]string
+	stats map[string]*statementStats
+}
+
+// newStatementRegistry creates the registry of the statements, failing if a name is
+// declared twice with different SQL, as the name would be ambiguous.
+func newStatementRegistry(statements []Statement) (*statementRegistry, error) {
+	r := &statementRegistry{
+		sql:   make(map[string]string, len(statements)),
+		stats: make(map[string]*statementStats, len(statements)),
+	}
+
+	conflicts := []string{}
+
+	for _, statement := range statements {
+		if sql, ok := r.sql[statement.Name]; ok {
+			if sql != statement.SQL {
+				conflicts = append(conflicts, statement.Name)
+			}
+
+			continue
+		}
+
+		r.sql[statement.Name] = statement.SQL
+		r.stats[statement.Name] = &statementStats{}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, errors.Newf("Statements declared with different SQL: %v", conflicts)
+	}
+
+	return r, nil
+}
+
+// lookup returns the SQL of the statement with the given name, if registered.
+func (r *statementRegistry) lookup(name string) (string, bool) {
+	sql, ok := r.sql[name]
+
+	return sql, ok
+}
+
+// connect prepares every registered statement once conn is established. Failing to prepare
+// any of them fails the connection, which the pool then discards instead of handing it out.
+func (r *statementRegistry) connect(ctx context.Context, conn *pgx.Conn) error {
+	for name, sql := range r.sql {
+		if _, err := conn.Prepare(ctx, name, sql); err != nil {
+			return errors.Wrapf(err, "Cannot prepare statement %s", name)
+		}
+	}
+
+	return nil
+}
+
+// observe records a call of the statement with the given name, if registered.
+func (r *statementRegistry) observe(name string, duration time.Duration, err error) {
+	stats, ok := r.stats[name]
+
+	if !ok {
+		return
+	}
+
+	atomic.AddUint64(&stats.calls, 1)
+	atomic.AddInt64(&stats.duration, int64(duration))
+
+	if err != nil {
+		atomic.AddUint64(&stats.errors, 1)
+	}
+}
+
+func (r *statementRegistry) metrics() []StatementMetrics {
+	metrics := make([]StatementMetrics, 0, len(r.stats))
+	for name, stats := range r.stats {
+		metrics = append(metrics, StatementMetrics{
+			Name:     name,
+			Calls:    atomic.LoadUint64(&stats.calls),
+			Errors:   atomic.LoadUint64(&stats.errors),
+			Duration: time.Duration(atomic.LoadInt64(&stats.duration)),
+		})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+
+	return metrics
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgx/v4"
+)
+
+// TransactionOption configures how a transaction is started.
+type TransactionOption func(*transactionOptions)
+
+type transactionOptions struct {
+	pgx.TxOptions
+	statementTimeout time.Duration
+	noRetry          bool
+}
+
+func newTransactionOptions(options []TransactionOption) transactionOptions {
+	opts := transactionOptions{
+		TxOptions: pgx.TxOptions{
+			IsoLevel:   pgx.Serializable,
+			AccessMode: pgx.ReadWrite,
+		},
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return opts
+}
+
+// IsoLevel sets the isolation level of the transaction, serializable by default.
+func IsoLevel(level pgx.TxIsoLevel) TransactionOption {
+	return func(opts *transactionOptions) {
+		opts.IsoLevel = level
+	}
+}
+
+// ReadOnly makes the transaction read-only.
+func ReadOnly() TransactionOption {
+	return func(opts *transactionOptions) {
+		opts.AccessMode = pgx.ReadOnly
+	}
+}
+
+// Deferrable makes the transaction deferrable. Only serializable read-only transactions are affected,
+// which then wait for a safe snapshot and never fail due to serialization failures.
+func Deferrable() TransactionOption {
+	return func(opts *transactionOptions) {
+		opts.DeferrableMode = pgx.Deferrable
+	}
+}
+
+// StatementTimeout aborts any statement of the transaction that takes longer than timeout.
+func StatementTimeout(timeout time.Duration) TransactionOption {
+	return func(opts *transactionOptions) {
+		opts.statementTimeout = timeout
+	}
+}
+
+// NoRetry runs the transaction only once, even if it fails with a retryable error, for closures with
+// effects outside of the database, such as streaming rows to a client, that cannot be repeated.
+func NoRetry() TransactionOption {
+	return func(opts *transactionOptions) {
+		opts.noRetry = true
+	}
+}
+
+// apply sets the options that cannot be given when beginning the transaction.
+func (opts transactionOptions) apply(ctx context.Context, tx pgx.Tx) error {
+	if opts.statementTimeout > 0 {
+		_, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d;", opts.statementTimeout.Milliseconds()))
+		if err != nil {
+			return errors.Wrap(err, "Cannot set transaction statement timeout")
+		}
+	}
+
+	return nil
+}
@@ -1,54 +1,57 @@
 package exception
 
 import (
+	"encoding/json"
 	"fmt"
-	"math/rand"
 	"net/http"
-	"runtime"
 
 	"github.com/cockroachdb/errors"
-	"github.com/labstack/echo/v4"
 )
 
 type exception interface {
-	origin() int
 	inner() error
 	status() int
 	message() string
+	description() string
+	metadata() map[string]interface{}
 	Wrap(err error, msg string) error
 	New(msg string) error
+	With(key string, value interface{}) Exception
 	Is(reference error) bool
 	Error() string
 	Unwrap() error
 	String() string
 }
 
-// Exception describes a complex error.
+// Exception describes a complex error identified by its message code, so that it is the same
+// exception across builds, processes and services.
 type Exception struct {
-	_origin int    `json:"-"`
-	_inner  error  `json:"-"`
-	Status  int    `json:"-"`
-	Message string `json:"message"`
+	_inner      error     `json:"-"`
+	_detail     string    `json:"-"`
+	_metadata   *metadata `json:"-"`
+	Status      int       `json:"-"`
+	Message     string    `json:"message"`
+	Description string    `json:"-"`
 }
 
-// New creates a new Exception instance.
-func New(status int, message string) Exception {
-	origin := rand.Intn(2048) // nolint
-	if pc, _, _, ok := runtime.Caller(1); ok {
-		origin = int(pc)
-	}
+// metadata holds the structured context of an exception behind a pointer,
+// keeping exceptions comparable with == as errors.Is does.
+type metadata struct {
+	values map[string]interface{}
+}
+
+// New creates a new Exception instance identified by the message code,
+// registering it in the catalog with the given description.
+func New(status int, message string, description string) Exception {
+	register(Entry{Code: message, Status: status, Description: description})
 
 	return Exception{
-		_origin: origin,
-		Status:  status,
-		Message: message,
+		Status:      status,
+		Message:     message,
+		Description: description,
 	}
 }
 
-func (e Exception) origin() int {
-	return e._origin
-}
-
 func (e Exception) inner() error {
 	return e._inner
 }
@@ -61,30 +64,57 @@ func (e Exception) message() string {
 	return e.Message
 }
 
+func (e Exception) description() string {
+	return e.Description
+}
+
+func (e Exception) metadata() map[string]interface{} {
+	if e._metadata == nil {
+		return nil
+	}
+
+	return e._metadata.values
+}
+
 // Wrap wraps err with msg in exception.
 func (e Exception) Wrap(err error, msg string) error {
-	return Exception{
-		_origin: e._origin,
-		_inner:  errors.Wrap(err, msg),
-		Status:  e.Status,
-		Message: e.Message,
-	}
+	e._inner = errors.Wrap(err, msg)
+	e._detail = msg
+
+	return e
 }
 
 // New wraps msg in exception.
 func (e Exception) New(msg string) error {
-	return Exception{
-		_origin: e._origin,
-		_inner:  errors.New(msg),
-		Status:  e.Status,
-		Message: e.Message,
+	e._inner = errors.New(msg)
+	e._detail = msg
+
+	return e
+}
+
+// With returns a copy of the exception carrying the value under key in its metadata,
+// such as the username that clashed, which is returned to the client.
+func (e Exception) With(key string, value interface{}) Exception {
+	values := make(map[string]interface{}, len(e.metadata())+1)
+	for k, v := range e.metadata() {
+		values[k] = v
 	}
+
+	values[key] = value
+	e._metadata = &metadata{values: values}
+
+	return e
 }
 
-// Is checks whether the exception is the given reference error.
+// Metadata returns the structured context of the exception, if any.
+func (e Exception) Metadata() map[string]interface{} {
+	return e.metadata()
+}
+
+// Is checks whether the exception is the given reference error, that is, whether both share the code.
 func (e Exception) Is(reference error) bool {
 	if other, ok := reference.(exception); ok { // nolint
-		return e._origin == other.origin()
+		return e.Message == other.message()
 	}
 
 	return false
@@ -105,34 +135,56 @@ func (e Exception) String() string {
 	return fmt.Sprintf("<%s: %d>", e.Message, e.Status)
 }
 
-// ErrGeneric generic error.
-var ErrGeneric = New(http.StatusInternalServerError, "ERR_GENERIC")
-
-// Handler controls all the returned exceptions.
-func Handler(err error, ctx echo.Context) {
-	if exc, ok := err.(exception); ok { // nolint
-		ctx.Logger().Error(exc.inner()) // TODO(alex): Send to Sentry with ctx.
-		returnException(exc, ctx)
-	} else { // Fallback.
-		ctx.Logger().Error(err)
-		returnException(ErrGeneric, ctx)
-	}
+// wireException describes an exception as propagated between services.
+type wireException struct {
+	Code        string                 `json:"code"`
+	Status      int                    `json:"status"`
+	Description string                 `json:"description,omitempty"`
+	Detail      string                 `json:"detail,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
-func returnException(exc exception, ctx echo.Context) {
-	var err error
+// MarshalJSON satisfies the json.Marshaler interface, serializing the exception to propagate it to other services.
+// The wrapped errors are not serialized, only the detail message they were wrapped with.
+func (e Exception) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wireException{
+		Code:        e.Message,
+		Status:      e.Status,
+		Description: e.Description,
+		Detail:      e._detail,
+		Metadata:    e.metadata(),
+	})
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface, deserializing an exception propagated by another service,
+// which is the same exception as the local one with the same code, if any.
+func (e *Exception) UnmarshalJSON(data []byte) error {
+	var wire wireException
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err // nolint
+	}
+
+	if wire.Code == "" {
+		return errors.New("Cannot deserialize exception without code")
+	}
 
-	if ctx.Response().Committed {
-		return
+	*e = Exception{
+		Status:      wire.Status,
+		Message:     wire.Code,
+		Description: wire.Description,
 	}
 
-	if ctx.Request().Method == http.MethodHead {
-		err = ctx.NoContent(exc.status())
-	} else {
-		err = ctx.JSON(exc.status(), exc)
+	if wire.Detail != "" {
+		e._inner = errors.New(wire.Detail)
+		e._detail = wire.Detail
 	}
 
-	if err != nil {
-		ctx.Logger().Error(errors.Wrap(err, "Cannot return exception"))
+	if len(wire.Metadata) > 0 {
+		e._metadata = &metadata{values: wire.Metadata}
 	}
+
+	return nil
 }
+
+// ErrGeneric generic error.
+var ErrGeneric = New(http.StatusInternalServerError, "ERR_GENERIC", "An unexpected error occurred.")
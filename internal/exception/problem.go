@@ -0,0 +1,124 @@
+package exception
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEApplicationProblemJSON is the media type of the problem details of RFC 7807.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ProblemTypeBase is the URI the problem types are relative to, the error catalog.
+const ProblemTypeBase = "/v1/errors#"
+
+// Problem describes an error response as the problem details of RFC 7807,
+// extended with the exception code and metadata, the request id and the invalid fields.
+// It carries no detail, as the messages exceptions are wrapped with are internal and only logged.
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Instance  string                 `json:"instance"`
+	Code      string                 `json:"code,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Errors    []FieldError           `json:"errors,omitempty"`
+}
+
+// FieldError describes a field of the request that failed validation.
+type FieldError struct {
+	Field string `json:"field"`
+	Tag   string `json:"tag"`
+	Param string `json:"param,omitempty"`
+}
+
+// NewProblem creates a new Problem instance for the exception returned by the request.
+func NewProblem(exc exception, ctx echo.Context) *Problem {
+	problem := newProblem(ctx, exc.status())
+	problem.Type = ProblemTypeBase + exc.message()
+	problem.Title = exc.description()
+	problem.Code = exc.message()
+	problem.Metadata = exc.metadata()
+
+	var verrs validator.ValidationErrors
+	if errors.As(exc.inner(), &verrs) {
+		problem.Errors = make([]FieldError, 0, len(verrs))
+
+		for _, verr := range verrs {
+			problem.Errors = append(problem.Errors, FieldError{
+				Field: fieldPath(verr.Namespace()),
+				Tag:   verr.Tag(),
+				Param: verr.Param(),
+			})
+		}
+	}
+
+	return problem
+}
+
+// newProblem creates a problem without a specific type, as the status code is enough to describe it.
+func newProblem(ctx echo.Context, status int) *Problem {
+	return &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  ctx.Request().URL.Path,
+		RequestID: ctx.Response().Header().Get(echo.HeaderXRequestID),
+	}
+}
+
+// fieldPath strips the name of the request struct from a validation namespace, e.g. users[0].name.
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return namespace
+}
+
+// Handler controls all the returned exceptions.
+func Handler(err error, ctx echo.Context) {
+	var problem *Problem
+	var exc Exception
+	var herr *echo.HTTPError
+
+	if errors.As(err, &exc) {
+		// The exception code along with the whole chain of messages and causes it wraps.
+		ctx.Logger().Errorf("%s: %v", exc.message(), exc.inner()) // TODO(alex): Send to Sentry with ctx.
+		problem = NewProblem(exc, ctx)
+	} else if errors.As(err, &herr) {
+		if herr.Code >= http.StatusInternalServerError {
+			ctx.Logger().Error(err)
+		}
+
+		problem = newProblem(ctx, herr.Code)
+	} else { // Fallback.
+		ctx.Logger().Error(err)
+		problem = NewProblem(ErrGeneric, ctx)
+	}
+
+	returnProblem(problem, ctx)
+}
+
+func returnProblem(problem *Problem, ctx echo.Context) {
+	var err error
+
+	if ctx.Response().Committed {
+		return
+	}
+
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(problem.Status)
+	} else {
+		ctx.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+		err = ctx.JSON(problem.Status, problem)
+	}
+
+	if err != nil {
+		ctx.Logger().Error(errors.Wrap(err, "Cannot return exception"))
+	}
+}
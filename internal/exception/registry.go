@@ -0,0 +1,64 @@
+package exception
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Entry describes a registered exception in the catalog.
+type Entry struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// registry holds every exception created with New, in creation order.
+var registry = struct {
+	sync.Mutex
+	entries []Entry
+}{}
+
+func register(entry Entry) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.entries = append(registry.entries, entry)
+}
+
+// Catalog returns every registered exception sorted by code.
+func Catalog() []Entry {
+	registry.Lock()
+	defer registry.Unlock()
+
+	entries := make([]Entry, len(registry.entries))
+	copy(entries, registry.entries)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+
+	return entries
+}
+
+// Verify checks that no two registered exceptions share a code, so that clients can rely on codes.
+func Verify() error {
+	seen := map[string]bool{}
+	duplicates := []string{}
+
+	for _, entry := range Catalog() {
+		if seen[entry.Code] {
+			duplicates = append(duplicates, entry.Code)
+		}
+
+		seen[entry.Code] = true
+	}
+
+	if len(duplicates) > 0 {
+		return errors.Newf("Exception codes registered more than once: %s", strings.Join(duplicates, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgxutil"
+	"github.com/labstack/echo/v4"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/internal/exception"
+)
+
+// HeaderIdempotencyKey is the header clients use to make a request idempotent.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed is the header set on responses replayed from a previous request.
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+// MaxKeyLength maximum length of an idempotency key.
+const MaxKeyLength = 255
+
+var (
+	// ErrInvalidIdempotencyKey idempotency key is too long.
+	ErrInvalidIdempotencyKey = exception.New(http.StatusBadRequest, "ERR_INVALID_IDEMPOTENCY_KEY",
+		"The Idempotency-Key header is longer than the maximum allowed length.")
+
+	// ErrIdempotencyKeyReused idempotency key was already used with a different request body.
+	ErrIdempotencyKeyReused = exception.New(http.StatusUnprocessableEntity, "ERR_IDEMPOTENCY_KEY_REUSED",
+		"The idempotency key was already used with a different request body.")
+
+	// ErrIdempotencyKeyInProgress a request with the same idempotency key is still being processed.
+	ErrIdempotencyKeyInProgress = exception.New(http.StatusConflict, "ERR_IDEMPOTENCY_KEY_IN_PROGRESS",
+		"A request with the same idempotency key is still being processed.")
+)
+
+// Tables of the Idempotency.
+const (
+	keysTable = "idempotency_keys"
+)
+
+// Statements of the Idempotency, named after their table and method.
+const (
+	claimStatement    = "idempotency_keys.claim"
+	getStatement      = "idempotency_keys.get"
+	completeStatement = "idempotency_keys.complete"
+	releaseStatement  = "idempotency_keys.release"
+	purgeStatement    = "idempotency_keys.purge"
+)
+
+// Statements returns the statements of the Idempotency, to be prepared on every database connection.
+func Statements() []database.Statement {
+	return []database.Statement{
+		{Name: claimStatement, SQL: fmt.Sprintf(
+			`INSERT INTO "%[1]s" ("key", "method", "path", "fingerprint", "expires_at")
+			 VALUES ($1, $2, $3, $4, $5)
+			 ON CONFLICT ("key", "method", "path") DO UPDATE
+			 SET "fingerprint" = EXCLUDED."fingerprint", "status" = NULL, "content_type" = NULL, "headers" = NULL,
+			     "body" = NULL, "created_at" = NOW(), "expires_at" = EXCLUDED."expires_at"
+			 WHERE "%[1]s"."expires_at" <= NOW()
+			 RETURNING TRUE;`, keysTable)},
+		{Name: getStatement, SQL: fmt.Sprintf(
+			`SELECT "fingerprint", "status", COALESCE("content_type", '') AS "content_type",
+			        COALESCE("headers", '{}') AS "headers", "body"
+			 FROM "%s"
+			 WHERE "key" = $1 AND "method" = $2 AND "path" = $3;`, keysTable)},
+		{Name: completeStatement, SQL: fmt.Sprintf(
+			`UPDATE "%s" SET "status" = $4, "content_type" = $5, "headers" = $6, "body" = $7
+			 WHERE "key" = $1 AND "method" = $2 AND "path" = $3;`, keysTable)},
+		{Name: releaseStatement, SQL: fmt.Sprintf(
+			`DELETE FROM "%s" WHERE "key" = $1 AND "method" = $2 AND "path" = $3;`, keysTable)},
+		{Name: purgeStatement, SQL: fmt.Sprintf(
+			`DELETE FROM "%[1]s"
+			 WHERE ("key", "method", "path") IN (
+				 SELECT "key", "method", "path" FROM "%[1]s"
+				 WHERE "expires_at" <= NOW()
+				 LIMIT $1);`, keysTable)},
+	}
+}
+
+// Configuration describes the idempotency configuration.
+type Configuration struct {
+	TTL time.Duration
+}
+
+// Idempotency stores and replays the responses of requests with an Idempotency-Key header.
+// Its statements must be registered in the configuration of the database of cn.
+type Idempotency struct {
+	cn            database.Connection
+	configuration Configuration
+}
+
+// New creates a new Idempotency instance.
+func New(cn database.Connection, configuration Configuration) *Idempotency {
+	return &Idempotency{
+		cn:            cn,
+		configuration: configuration,
+	}
+}
+
+type record struct {
+	Fingerprint string      `db:"fingerprint"`
+	Status      *int        `db:"status"`
+	ContentType string      `db:"content_type"`
+	Headers     http.Header `db:"headers"`
+	Body        []byte      `db:"body"`
+}
+
+// Middleware implements echo.MiddlewareFunc interface.
+func (i *Idempotency) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			key := req.Header.Get(HeaderIdempotencyKey)
+
+			if req.Method != http.MethodPost || key == "" {
+				return next(ctx)
+			}
+
+			if len(key) > MaxKeyLength {
+				return ErrInvalidIdempotencyKey.New("Cannot use an idempotency key that long")
+			}
+
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return errors.Wrap(err, "Cannot read idempotent request body")
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			claimed, err := i.claim(req.Context(), key, req.Method, ctx.Path(), fingerprint)
+			if err != nil {
+				return err
+			}
+
+			if !claimed {
+				return i.replay(ctx, key, fingerprint)
+			}
+
+			return i.record(ctx, next, key)
+		}
+	}
+}
+
+// claim tries to take ownership of the key, also reclaiming it if it has expired.
+func (i *Idempotency) claim(ctx context.Context, key string, method string, path string,
+	fingerprint string) (bool, error) {
+	_, err := pgxutil.SelectBool(ctx, i.cn, claimStatement,
+		key, method, path, fingerprint, time.Now().Add(i.configuration.TTL))
+	if err != nil {
+		if errors.Is(database.Error(err), database.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(database.Error(err), "Cannot claim idempotency key")
+	}
+
+	return true, nil
+}
+
+// replay returns the stored response of a previous request with the same key, including its headers.
+func (i *Idempotency) replay(ctx echo.Context, key string, fingerprint string) error {
+	var r record
+
+	err := pgxutil.SelectStruct(ctx.Request().Context(), i.cn, &r, getStatement,
+		key, ctx.Request().Method, ctx.Path())
+	if err != nil {
+		return errors.Wrap(database.Error(err), "Cannot get idempotency key")
+	}
+
+	if r.Fingerprint != fingerprint {
+		return ErrIdempotencyKeyReused.New("Cannot reuse idempotency key with a different request")
+	}
+
+	if r.Status == nil {
+		return ErrIdempotencyKeyInProgress.New("Cannot replay a request still in progress")
+	}
+
+	for name, values := range r.Headers {
+		ctx.Response().Header()[name] = values
+	}
+
+	ctx.Response().Header().Set(HeaderIdempotentReplayed, "true")
+
+	return ctx.Blob(*r.Status, r.ContentType, r.Body)
+}
+
+// record runs the request and stores its response under the claimed key, with the headers set while running it,
+// leaving out the ones set beforehand by the outer middlewares for every request, such as the request id.
+// Server errors and panics release the key so that the request can be retried.
+func (i *Idempotency) record(ctx echo.Context, next echo.HandlerFunc, key string) error {
+	res := ctx.Response()
+	buffer := new(bytes.Buffer)
+	res.Writer = &recorder{ResponseWriter: res.Writer, body: buffer}
+	before := res.Header().Clone()
+
+	// The request context may be already canceled at this point.
+	rctx := context.Background()
+
+	release := func() error {
+		_, err := i.cn.Exec(rctx, releaseStatement,
+			key, ctx.Request().Method, ctx.Path())
+
+		return err // nolint
+	}
+
+	completed := false
+
+	defer func() {
+		if !completed {
+			if err := release(); err != nil {
+				ctx.Logger().Error(errors.Wrap(database.Error(err), "Cannot release idempotency key"))
+			}
+		}
+	}()
+
+	if err := next(ctx); err != nil {
+		ctx.Error(err)
+	}
+
+	completed = true
+
+	var err error
+	if res.Status >= http.StatusInternalServerError {
+		err = release()
+	} else {
+		headers := http.Header{}
+		for name, values := range res.Header() {
+			if !reflect.DeepEqual(before[name], values) {
+				headers[name] = values
+			}
+		}
+
+		_, err = i.cn.Exec(rctx, completeStatement,
+			key, ctx.Request().Method, ctx.Path(), res.Status, res.Header().Get(echo.HeaderContentType), headers,
+			buffer.Bytes())
+	}
+
+	if err != nil {
+		ctx.Logger().Error(errors.Wrap(database.Error(err), "Cannot store idempotency key response"))
+	}
+
+	return nil
+}
+
+// Purge deletes up to limit expired keys, returning how many were deleted.
+// Expired keys are otherwise only reclaimed when the same key is reused.
+func (i *Idempotency) Purge(ctx context.Context, limit int) (int, error) {
+	tag, err := i.cn.Exec(ctx, purgeStatement,
+		limit)
+	if err != nil {
+		return 0, errors.Wrap(database.Error(err), "Cannot purge expired idempotency keys")
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+type recorder struct {
+	http.ResponseWriter
+	body io.Writer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b) // nolint
+
+	return r.ResponseWriter.Write(b) // nolint
+}
+
+func (r *recorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
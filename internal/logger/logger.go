@@ -321,6 +321,7 @@ func (l Logger) Middleware(level zerolog.Level) echo.MiddlewareFunc {
 			logger.Info().
 				Str("method", req.Method).
 				Str("path", req.RequestURI).
+				Str("request_id", res.Header().Get(echo.HeaderXRequestID)).
 				Int("status", res.Status).
 				Str("ip_address", ctx.RealIP()).
 				Str("user_agent", req.UserAgent()).
@@ -4,6 +4,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/cockroachdb/errors"
 )
 
 // Schemes enumerates the possible schemes.
@@ -20,6 +22,20 @@ var Environments = struct {
 	TESTING     string
 }{"production", "staging", "development", "testing"}
 
+// MigrationModes enumerates the possible ways of handling migrations on startup.
+var MigrationModes = struct {
+	AUTO   string
+	VERIFY string
+	SKIP   string
+}{"auto", "verify", "skip"}
+
+// DriftChecks enumerates the possible ways of handling schema drift on startup.
+var DriftChecks = struct {
+	OFF  string
+	WARN string
+	FAIL string
+}{"off", "warn", "fail"}
+
 type (
 	_app struct {
 		Host            []string
@@ -30,6 +46,10 @@ type (
 		Version         string
 		Release         string
 		GracefulTimeout int
+		IdempotencyTTL  int
+
+		IdempotencyPurgeInterval  int
+		IdempotencyPurgeBatchSize int
 	}
 
 	_database struct {
@@ -39,17 +59,80 @@ type (
 		Password string
 		Name     string
 		SSLMode  string
+
+		TransactionAttempts int
+
+		Replicas             []string
+		MaxReplicaLag        int
+		ReadYourWritesWindow int
+
+		StatementTimeout   int
+		SlowQueryThreshold int
+
+		MaxConnLifetime   int
+		MaxConnIdleTime   int
+		HealthCheckPeriod int
+
+		MigrationsDirectory  string
+		MigrationMode        string
+		MigrationLockTimeout int
+		DriftCheck           string
+	}
+
+	_outbox struct {
+		Sink        string
+		WebhookURL  string
+		Interval    int
+		BatchSize   int
+		MaxAttempts int
+	}
+
+	_webhook struct {
+		Interval    int
+		BatchSize   int
+		MaxAttempts int
+		Timeout     int
 	}
 
 	// Configuration describes the application configuration.
 	Configuration struct {
 		App      _app
 		Database _database
+		Outbox   _outbox
+		Webhook  _webhook
 	}
 )
 
-func (s *Server) addConfiguration() error { // nolint
-	s.Configuration = Configuration{
+func (s *Server) addConfiguration() error {
+	s.Configuration = NewConfiguration()
+
+	return s.Configuration.validate()
+}
+
+// validate checks the configuration values that would otherwise make the server fail once running.
+func (c Configuration) validate() error {
+	intervals := []struct {
+		key   string
+		value int
+	}{
+		{"OUTBOX_INTERVAL", c.Outbox.Interval},
+		{"WEBHOOK_INTERVAL", c.Webhook.Interval},
+		{"ZEUS_IDEMPOTENCY_PURGE_INTERVAL", c.App.IdempotencyPurgeInterval},
+	}
+
+	for _, interval := range intervals {
+		if interval.value <= 0 {
+			return errors.Newf("Configuration %s must be a positive number of seconds, got %d",
+				interval.key, interval.value)
+		}
+	}
+
+	return nil
+}
+
+// NewConfiguration loads the application configuration from the environment.
+func NewConfiguration() Configuration { // nolint
+	return Configuration{
 		App: _app{
 			Host:            getEnvAsSlice("ZEUS_HOST", []string{"localhost"}),
 			Port:            getEnvAsInt("ZEUS_PORT", 1111),
@@ -59,6 +142,10 @@ func (s *Server) addConfiguration() error { // nolint
 			Version:         getEnvAsString("ZEUS_VERSION", "fakeVersion"),
 			Release:         getEnvAsString("ZEUS_RELEASE", "fakeRelease"),
 			GracefulTimeout: getEnvAsInt("ZEUS_GRACEFUL_TIMEOUT", 15),
+			IdempotencyTTL:  getEnvAsInt("ZEUS_IDEMPOTENCY_TTL", 86400),
+
+			IdempotencyPurgeInterval:  getEnvAsInt("ZEUS_IDEMPOTENCY_PURGE_INTERVAL", 300),
+			IdempotencyPurgeBatchSize: getEnvAsInt("ZEUS_IDEMPOTENCY_PURGE_BATCH_SIZE", 1000),
 		},
 
 		Database: _database{
@@ -68,10 +155,42 @@ func (s *Server) addConfiguration() error { // nolint
 			Password: getEnvAsString("DATABASE_PASSWORD", "zeus"),
 			Name:     getEnvAsString("DATABASE_NAME", "zeus"),
 			SSLMode:  getEnvAsString("DATABASE_SSLMODE", "disable"),
+
+			TransactionAttempts: getEnvAsInt("DATABASE_TRANSACTION_ATTEMPTS", 5),
+
+			Replicas:             getEnvAsSlice("DATABASE_REPLICAS", []string{}),
+			MaxReplicaLag:        getEnvAsInt("DATABASE_MAX_REPLICA_LAG", 10),
+			ReadYourWritesWindow: getEnvAsInt("DATABASE_READ_YOUR_WRITES_WINDOW", 5),
+
+			StatementTimeout:   getEnvAsInt("DATABASE_STATEMENT_TIMEOUT", 10000),
+			SlowQueryThreshold: getEnvAsInt("DATABASE_SLOW_QUERY_THRESHOLD", 500),
+
+			MaxConnLifetime:   getEnvAsInt("DATABASE_MAX_CONN_LIFETIME", 3600),
+			MaxConnIdleTime:   getEnvAsInt("DATABASE_MAX_CONN_IDLE_TIME", 1800),
+			HealthCheckPeriod: getEnvAsInt("DATABASE_HEALTH_CHECK_PERIOD", 60),
+
+			MigrationsDirectory:  getEnvAsString("DATABASE_MIGRATIONS_DIRECTORY", ""),
+			MigrationMode:        getEnvAsString("DATABASE_MIGRATION_MODE", "auto"),
+			MigrationLockTimeout: getEnvAsInt("DATABASE_MIGRATION_LOCK_TIMEOUT", 300),
+			DriftCheck:           getEnvAsString("DATABASE_DRIFT_CHECK", "off"),
+		},
+
+		Outbox: _outbox{
+			Sink:        getEnvAsString("OUTBOX_SINK", "stdout"),
+			WebhookURL:  getEnvAsString("OUTBOX_WEBHOOK_URL", ""),
+			Interval:    getEnvAsInt("OUTBOX_INTERVAL", 1),
+			BatchSize:   getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts: getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+		},
+
+		Webhook: _webhook{
+			Interval:    getEnvAsInt("WEBHOOK_INTERVAL", 1),
+			BatchSize:   getEnvAsInt("WEBHOOK_BATCH_SIZE", 100),
+			MaxAttempts: getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			Timeout:     getEnvAsInt("WEBHOOK_TIMEOUT", 10),
 		},
 	}
 
-	return nil
 }
 
 func getEnvAsString(key string, def string) string { // nolint
@@ -2,53 +2,134 @@ package server
 
 import (
 	"context"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/jackc/pgx/v4"
 	"github.com/rs/zerolog"
 
 	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/internal/idempotency"
 	"github.com/neoxelox/zeus/internal/logger"
+	"github.com/neoxelox/zeus/pkg/repository"
 )
 
 // Dependencies describes the application dependencies.
 type Dependencies struct {
-	Database *database.Database
+	Database    *database.Database
+	Idempotency *idempotency.Idempotency
 }
 
 func (s *Server) addDependencies(logger *logger.Logger) error {
+	database, err := database.New(context.Background(), 15, DatabaseConfiguration(s.Configuration, logger))
+	if err != nil {
+		return errors.Wrap(err, "Cannot add database dependency")
+	}
+
+	switch s.Configuration.Database.MigrationMode {
+	case MigrationModes.AUTO:
+		err = database.Migrate(context.Background())
+		if err != nil {
+			return errors.Wrap(err, "Cannot migrate database")
+		}
+	case MigrationModes.VERIFY:
+		err = database.VerifyMigrations(context.Background())
+		if err != nil {
+			return errors.Wrap(err, "Cannot verify database migrations")
+		}
+	case MigrationModes.SKIP:
+	default:
+		return errors.Newf("Unknown migration mode %s", s.Configuration.Database.MigrationMode)
+	}
+
+	switch s.Configuration.Database.DriftCheck {
+	case DriftChecks.WARN, DriftChecks.FAIL:
+		drifts, err := database.DetectDrift(context.Background())
+		if err != nil {
+			if s.Configuration.Database.DriftCheck == DriftChecks.FAIL {
+				return errors.Wrap(err, "Cannot detect database schema drift")
+			}
+
+			logger.Warnf("Cannot detect database schema drift: %v", err)
+		}
+
+		for _, drift := range drifts {
+			logger.Warn("Database schema drift: " + drift.String())
+		}
+
+		if len(drifts) > 0 && s.Configuration.Database.DriftCheck == DriftChecks.FAIL {
+			return errors.Newf("Database schema drifted from the migrations in %d objects", len(drifts))
+		}
+	case DriftChecks.OFF:
+	default:
+		return errors.Newf("Unknown drift check %s", s.Configuration.Database.DriftCheck)
+	}
+
+	s.Dependencies = Dependencies{
+		Database: database,
+		Idempotency: idempotency.New(database.Writer(), idempotency.Configuration{
+			TTL: time.Duration(s.Configuration.App.IdempotencyTTL) * time.Second,
+		}),
+	}
+
+	return nil
+}
+
+// DatabaseConfiguration creates the database configuration from the application configuration.
+func DatabaseConfiguration(configuration Configuration, logger *logger.Logger) database.Configuration {
 	zlogLevel := zerolog.InfoLevel
 	plogLevel := pgx.LogLevel(pgx.LogLevelError)
-	if s.Configuration.App.Environment == Environments.DEVELOPMENT {
+	if configuration.App.Environment == Environments.DEVELOPMENT {
 		zlogLevel = zerolog.DebugLevel
 		plogLevel = pgx.LogLevelDebug
 	}
 
-	database, err := database.New(context.Background(), 15, database.Configuration{
-		Host:     s.Configuration.Database.Host,
-		Port:     s.Configuration.Database.Port,
-		User:     s.Configuration.Database.User,
-		Password: s.Configuration.Database.Password,
-		Name:     s.Configuration.Database.Name,
-		SSLMode:  s.Configuration.Database.SSLMode,
+	return database.Configuration{
+		Host:     configuration.Database.Host,
+		Port:     configuration.Database.Port,
+		User:     configuration.Database.User,
+		Password: configuration.Database.Password,
+		Name:     configuration.Database.Name,
+		SSLMode:  configuration.Database.SSLMode,
 		MinConns: 0,  // nolint
 		MaxConns: 22, // nolint
-		AppName:  s.Configuration.App.Name,
+		AppName:  configuration.App.Name,
 		Logger:   logger.Database(zlogLevel),
 		LogLevel: plogLevel,
-	})
-	if err != nil {
-		return errors.Wrap(err, "Cannot add database dependency")
-	}
 
-	err = database.Migrate(context.Background())
-	if err != nil {
-		return errors.Wrap(err, "Cannot migrate database")
-	}
+		ConnectMinBackoff: 500 * time.Millisecond, // nolint
+		ConnectMaxBackoff: 5 * time.Second,        // nolint
 
-	s.Dependencies = Dependencies{
-		Database: database,
+		TransactionAttempts:   configuration.Database.TransactionAttempts,
+		TransactionMinBackoff: 10 * time.Millisecond, // nolint
+		TransactionMaxBackoff: 1 * time.Second,
+
+		Replicas:             configuration.Database.Replicas,
+		ReplicaCheckPeriod:   5 * time.Second, // nolint
+		MaxReplicaLag:        time.Duration(configuration.Database.MaxReplicaLag) * time.Second,
+		ReadYourWritesWindow: time.Duration(configuration.Database.ReadYourWritesWindow) * time.Second,
+
+		StatementTimeout:   time.Duration(configuration.Database.StatementTimeout) * time.Millisecond,
+		SlowQueryThreshold: time.Duration(configuration.Database.SlowQueryThreshold) * time.Millisecond,
+
+		MaxConnLifetime:   time.Duration(configuration.Database.MaxConnLifetime) * time.Second,
+		MaxConnIdleTime:   time.Duration(configuration.Database.MaxConnIdleTime) * time.Second,
+		HealthCheckPeriod: time.Duration(configuration.Database.HealthCheckPeriod) * time.Second,
+
+		MigrationsDirectory:  configuration.Database.MigrationsDirectory,
+		MigrationLockTimeout: time.Duration(configuration.Database.MigrationLockTimeout) * time.Second,
+
+		Statements: databaseStatements(),
 	}
+}
 
-	return nil
+// databaseStatements gathers the statements of every repository and of the idempotency,
+// prepared on every database connection.
+func databaseStatements() []database.Statement {
+	statements := idempotency.Statements()
+	statements = append(statements, repository.UserStatements()...)
+	statements = append(statements, repository.OutboxStatements()...)
+	statements = append(statements, repository.WebhookStatements()...)
+
+	return statements
 }
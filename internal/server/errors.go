@@ -0,0 +1,15 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/neoxelox/zeus/internal/exception"
+	"github.com/neoxelox/zeus/pkg/payload"
+)
+
+// Errors returns the catalog of every error code the API may return.
+func (s *Server) Errors(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, payload.NewErrorListResponse(exception.Catalog()))
+}
@@ -4,31 +4,44 @@ import (
 	"github.com/neoxelox/zeus/pkg/handler"
 	"github.com/neoxelox/zeus/pkg/repository"
 	"github.com/neoxelox/zeus/pkg/user"
+	"github.com/neoxelox/zeus/pkg/webhook"
 )
 
 // Handlers describes the application handlers.
 type Handlers struct {
-	User handler.UserHandler
+	User    handler.UserHandler
+	Webhook handler.WebhookHandler
 }
 
 func (s *Server) addHandlers() error { // nolint
 	// Repositories.
 
-	userDatabase := repository.NewUserDatabase(s.Dependencies.Database.Connection)
+	userDatabase := repository.NewUserDatabase(s.Dependencies.Database)
+	outboxDatabase := repository.NewOutboxDatabase(s.Dependencies.Database)
+	webhookDatabase := repository.NewWebhookDatabase(s.Dependencies.Database)
 
 	// Use Cases.
 
-	userCreator := user.NewCreator(userDatabase)
+	webhookDispatcher := webhook.NewDispatcher(webhookDatabase)
+	userCreator := user.NewCreator(userDatabase, outboxDatabase, webhookDispatcher)
 	userGetter := user.NewGetter(userDatabase)
+	userUpdater := user.NewUpdater(userDatabase, outboxDatabase, webhookDispatcher)
+	userDeleter := user.NewDeleter(userDatabase, outboxDatabase, webhookDispatcher)
+	userExporter := user.NewExporter(userDatabase)
+	webhookSubscriber := webhook.NewSubscriber(webhookDatabase, webhook.SubscriberConfiguration{
+		AllowPrivate: s.Configuration.App.Environment == Environments.DEVELOPMENT,
+	})
 
 	// Handlers.
 
-	userHandler := handler.NewUserHandler(userCreator, userGetter)
+	userHandler := handler.NewUserHandler(userCreator, userGetter, userUpdater, userDeleter, userExporter)
+	webhookHandler := handler.NewWebhookHandler(webhookSubscriber)
 
 	// Add to server.
 
 	s.Handlers = Handlers{
-		User: *userHandler,
+		User:    *userHandler,
+		Webhook: *webhookHandler,
 	}
 
 	return nil
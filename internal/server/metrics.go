@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Metrics exposes the database pool and statement statistics in the Prometheus text format.
+func (s *Server) Metrics(ctx echo.Context) error {
+	metrics := s.Dependencies.Database.Metrics()
+
+	var out strings.Builder
+
+	metric := func(kind string, name string, help string, value func(i int) interface{}) {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for i, pool := range metrics.Pools {
+			fmt.Fprintf(&out, "%s{pool=%q} %v\n", name, pool.Pool, value(i))
+		}
+	}
+	gauge := func(name string, help string, value func(i int) interface{}) { metric("gauge", name, help, value) }
+	counter := func(name string, help string, value func(i int) interface{}) { metric("counter", name, help, value) }
+
+	gauge("zeus_database_acquired_conns", "Connections currently acquired.",
+		func(i int) interface{} { return metrics.Pools[i].AcquiredConns })
+	gauge("zeus_database_idle_conns", "Connections currently idle.",
+		func(i int) interface{} { return metrics.Pools[i].IdleConns })
+	gauge("zeus_database_total_conns", "Connections currently open.",
+		func(i int) interface{} { return metrics.Pools[i].TotalConns })
+	gauge("zeus_database_max_conns", "Maximum size of the pool.",
+		func(i int) interface{} { return metrics.Pools[i].MaxConns })
+	counter("zeus_database_acquire_count_total", "Successful connection acquires.",
+		func(i int) interface{} { return metrics.Pools[i].AcquireCount })
+	counter("zeus_database_acquire_duration_seconds_total", "Time spent acquiring connections.",
+		func(i int) interface{} { return metrics.Pools[i].AcquireDuration.Seconds() })
+	counter("zeus_database_canceled_acquire_count_total", "Connection acquires canceled by their context.",
+		func(i int) interface{} { return metrics.Pools[i].CanceledAcquireCount })
+	counter("zeus_database_empty_acquire_count_total", "Connection acquires that waited for a connection.",
+		func(i int) interface{} { return metrics.Pools[i].EmptyAcquireCount })
+	counter("zeus_database_max_lifetime_destroy_count_total", "Connections destroyed for exceeding their lifetime.",
+		func(i int) interface{} { return metrics.Pools[i].MaxLifetimeDestroyCount })
+
+	statement := func(name string, help string, value func(i int) interface{}) {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for i, statement := range metrics.Statements {
+			fmt.Fprintf(&out, "%s{statement=%q} %v\n", name, statement.Name, value(i))
+		}
+	}
+
+	statement("zeus_database_statement_calls_total", "Executions of the statement.",
+		func(i int) interface{} { return metrics.Statements[i].Calls })
+	statement("zeus_database_statement_errors_total", "Failed executions of the statement.",
+		func(i int) interface{} { return metrics.Statements[i].Errors })
+	statement("zeus_database_statement_duration_seconds_total", "Time spent executing the statement.",
+		func(i int) interface{} { return metrics.Statements[i].Duration.Seconds() })
+
+	fmt.Fprintf(&out, "# HELP zeus_database_query_timeouts_total Queries that exceeded their timeout.\n"+
+		"# TYPE zeus_database_query_timeouts_total counter\nzeus_database_query_timeouts_total %d\n", metrics.Timeouts)
+
+	return ctx.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(out.String()))
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog"
 
+	"github.com/neoxelox/zeus/internal/database"
 	"github.com/neoxelox/zeus/internal/logger"
 )
 
@@ -22,26 +24,54 @@ func (s *Server) addRoutes(logger *logger.Logger) error { // nolint
 	}
 
 	s.Instance.Pre(middleware.RemoveTrailingSlash()) // TODO(alex): Move to Horae.
+	s.Instance.Use(middleware.RequestID())
 	s.Instance.Use(logger.Middleware(logLevel))
 	s.Instance.Use(middleware.Recover())
+	s.Instance.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ctx.SetRequest(ctx.Request().WithContext(database.WithSession(ctx.Request().Context())))
+
+			return next(ctx)
+		}
+	})
 	s.Instance.Use(middleware.CORSWithConfig(middleware.CORSConfig{ // TODO(alex): Move to Horae.
-		AllowOrigins: allowOrigins,
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodPut},
-		AllowHeaders: []string{"*"},
-		MaxAge:       86400, // nolint
+		AllowOrigins:  allowOrigins,
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodPut},
+		AllowHeaders:  []string{"*"},
+		ExposeHeaders: []string{"ETag", echo.HeaderXRequestID},
+		MaxAge:        86400, // nolint
+	}))
+	s.Instance.Use(middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{ // TODO(alex): Move to Horae.
+		Skipper: func(ctx echo.Context) bool { return ctx.Path() == "/v1/user/import" },
+		Limit:   "2M",
 	}))
-	s.Instance.Use(middleware.BodyLimit("2M")) // TODO(alex): Move to Horae.
+
+	idempotent := s.Dependencies.Idempotency.Middleware()
 
 	// Endpoints.
 
 	s.Instance.GET("/health", s.Health)
+	s.Instance.GET("/metrics", s.Metrics)
 
 	v1 := s.Instance.Group("/v1")
+	v1.GET("/errors", s.Errors)
 
 	user := v1.Group("/user")
 	user.GET("", s.Handlers.User.List)
+	user.GET("/export", s.Handlers.User.Export)
+	// Imports are not idempotent, as it would buffer their streamed body, but rows already imported fail as
+	// existing usernames when retried.
+	user.POST("/import", s.Handlers.User.Import, middleware.BodyLimit("64M"))
 	user.GET("/:id", s.Handlers.User.GetByID)
-	user.POST("", s.Handlers.User.Create)
+	user.POST("", s.Handlers.User.Create, idempotent)
+	user.PUT("/:id", s.Handlers.User.Update)
+	user.DELETE("/:id", s.Handlers.User.Delete)
+
+	webhook := v1.Group("/webhook")
+	webhook.GET("", s.Handlers.Webhook.List)
+	webhook.POST("", s.Handlers.Webhook.Create, idempotent)
+	webhook.DELETE("/:id", s.Handlers.Webhook.Delete)
+	webhook.GET("/:id/delivery", s.Handlers.Webhook.ListDeliveries)
 
 	return nil
 }
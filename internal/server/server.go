@@ -19,6 +19,7 @@ type Server struct {
 	Configuration Configuration
 	Dependencies  Dependencies
 	Handlers      Handlers
+	Workers       Workers
 }
 
 // NewServer creates a new Server instance.
@@ -29,6 +30,10 @@ func New(e *echo.Echo) *Server {
 		panic(fmt.Sprintf("Cannot add server configuration\n %+v", err))
 	}
 
+	if err := exception.Verify(); err != nil {
+		panic(fmt.Sprintf("Cannot verify exception catalog\n %+v", err))
+	}
+
 	debug := false
 	logLevel := zerolog.InfoLevel
 	if server.Configuration.App.Environment == Environments.DEVELOPMENT {
@@ -54,6 +59,14 @@ func New(e *echo.Echo) *Server {
 		server.Instance.Logger.Panicf("Cannot add server handlers\n %+v", err)
 	}
 
+	if err := server.addWorkers(); err != nil {
+		server.Instance.Logger.Panicf("Cannot add server workers\n %+v", err)
+	}
+
+	if err := server.Dependencies.Database.Prepare(context.Background()); err != nil {
+		server.Instance.Logger.Panicf("Cannot prepare database statements\n %+v", err)
+	}
+
 	if err := server.addRoutes(appLogger); err != nil {
 		server.Instance.Logger.Panicf("Cannot add server routes\n %+v", err)
 	}
@@ -64,6 +77,7 @@ func New(e *echo.Echo) *Server {
 // Startup starts the server.
 func (s *Server) Startup() {
 	s.Instance.Logger.Info("Server startup")
+	s.startWorkers()
 	s.Instance.Logger.Fatal(s.Instance.Start(fmt.Sprintf(":%d", s.Configuration.App.Port)))
 }
 
@@ -74,6 +88,10 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	// 	deadline = time.Until(ctxDeadline)
 	// }.
 
+	if err := s.stopWorkers(ctx); err != nil {
+		return errors.Wrap(err, "Cannot stop workers")
+	}
+
 	if err := s.Dependencies.Database.Close(ctx); err != nil {
 		return errors.Wrap(err, "Cannot close connection to the database")
 	}
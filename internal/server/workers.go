@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/pkg/event"
+	"github.com/neoxelox/zeus/pkg/repository"
+	"github.com/neoxelox/zeus/pkg/webhook"
+)
+
+// Workers describes the application background workers.
+type Workers struct {
+	EventRelay       event.RelayUseCase
+	WebhookDeliverer webhook.DelivererUseCase
+	stop             context.CancelFunc
+	group            sync.WaitGroup
+}
+
+func (s *Server) addWorkers() error {
+	// Repositories.
+
+	outboxDatabase := repository.NewOutboxDatabase(s.Dependencies.Database)
+	webhookDatabase := repository.NewWebhookDatabase(s.Dependencies.Database)
+
+	// Sinks.
+
+	var sink event.Sink
+
+	switch s.Configuration.Outbox.Sink {
+	case event.Sinks.WEBHOOK:
+		if s.Configuration.Outbox.WebhookURL == "" {
+			return errors.New("Outbox webhook sink requires a webhook URL")
+		}
+
+		sink = event.NewWebhookSink(s.Configuration.Outbox.WebhookURL, &http.Client{
+			Timeout: 10 * time.Second, // nolint
+		})
+	case event.Sinks.STDOUT:
+		sink = event.NewStdoutSink(os.Stdout)
+	case event.Sinks.MEMORY:
+		// The memory sink grows unbounded, so it is only meant for the tests to inspect the published events.
+		if s.Configuration.App.Environment != Environments.TESTING {
+			return errors.Newf("Outbox memory sink is only available in the %s environment", Environments.TESTING)
+		}
+
+		sink = event.NewMemorySink()
+	default:
+		return errors.Newf("Unknown outbox sink %s", s.Configuration.Outbox.Sink)
+	}
+
+	// Use Cases.
+
+	webhookDeliverer := webhook.NewDeliverer(webhookDatabase, webhook.NewClient(webhook.ClientConfiguration{
+		Timeout:      time.Duration(s.Configuration.Webhook.Timeout) * time.Second,
+		AllowPrivate: s.Configuration.App.Environment == Environments.DEVELOPMENT,
+	}), webhook.DelivererConfiguration{
+		BatchSize:   s.Configuration.Webhook.BatchSize,
+		MaxAttempts: s.Configuration.Webhook.MaxAttempts,
+		Lease:       15 * time.Minute, // nolint
+		MinBackoff:  5 * time.Second,  // nolint
+		MaxBackoff:  6 * time.Hour,    // nolint
+	})
+
+	eventRelay := event.NewRelay(outboxDatabase, sink,
+		event.RelayConfiguration{
+			BatchSize:   s.Configuration.Outbox.BatchSize,
+			MaxAttempts: s.Configuration.Outbox.MaxAttempts,
+			Lease:       15 * time.Minute, // nolint
+			MinBackoff:  1 * time.Second,
+			MaxBackoff:  1 * time.Hour,
+		})
+
+	// Add to server.
+
+	s.Workers = Workers{
+		EventRelay:       eventRelay,
+		WebhookDeliverer: webhookDeliverer,
+	}
+
+	return nil
+}
+
+func (s *Server) startWorkers() {
+	ctx, stop := context.WithCancel(context.Background())
+	s.Workers.stop = stop
+
+	s.runWorker(ctx, time.Duration(s.Configuration.Outbox.Interval)*time.Second,
+		s.Configuration.Outbox.BatchSize, s.Workers.EventRelay.Relay)
+
+	s.runWorker(ctx, time.Duration(s.Configuration.Webhook.Interval)*time.Second,
+		s.Configuration.Webhook.BatchSize, s.Workers.WebhookDeliverer.Deliver)
+
+	s.runWorker(ctx, time.Duration(s.Configuration.App.IdempotencyPurgeInterval)*time.Second,
+		s.Configuration.App.IdempotencyPurgeBatchSize, func(ctx context.Context) (int, error) {
+			return s.Dependencies.Idempotency.Purge(ctx, s.Configuration.App.IdempotencyPurgeBatchSize)
+		})
+}
+
+func (s *Server) stopWorkers(ctx context.Context) error {
+	if s.Workers.stop == nil {
+		return nil
+	}
+
+	s.Workers.stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.Workers.group.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "Cannot wait for workers to stop")
+	}
+}
+
+// runWorker calls work periodically in background until ctx is done,
+// calling it again right away while it processes full batches.
+func (s *Server) runWorker(ctx context.Context, interval time.Duration, batchSize int,
+	work func(context.Context) (int, error)) {
+	s.Workers.group.Add(1)
+
+	go func() {
+		defer s.Workers.group.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for {
+					processed, err := work(ctx)
+					if err != nil {
+						if ctx.Err() == nil {
+							s.Instance.Logger.Error(err)
+						}
+
+						break
+					}
+
+					if processed < batchSize {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
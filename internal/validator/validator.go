@@ -1,6 +1,9 @@
 package validator
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -21,7 +24,26 @@ func (v *Validator) Validate(i interface{}) error {
 
 // New creates a new Validator.
 func New() *Validator {
+	v := validator.New()
+	v.RegisterTagNameFunc(fieldName)
+
 	return &Validator{
-		validator: validator.New(),
+		validator: v,
 	}
 }
+
+// fieldName names the fields in validation errors as the clients send them.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "param", "query", "header", "form"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return ""
+		}
+
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
@@ -0,0 +1,12 @@
+CREATE TABLE "idempotency_keys" (
+    "key"           VARCHAR(255) NOT NULL,
+    "method"        VARCHAR(10) NOT NULL,
+    "path"          VARCHAR(255) NOT NULL,
+    "fingerprint"   CHAR(64) NOT NULL,
+    "status"        INTEGER NULL,
+    "content_type"  VARCHAR(255) NULL,
+    "body"          BYTEA NULL,
+    "created_at"    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "expires_at"    TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY ("key", "method", "path")
+);
@@ -0,0 +1,13 @@
+CREATE TABLE "outbox" (
+    "id"                VARCHAR(20) PRIMARY KEY,
+    "type"              VARCHAR(100) NOT NULL,
+    "aggregate_id"      VARCHAR(20) NOT NULL,
+    "payload"           JSONB NOT NULL,
+    "attempts"          INTEGER NOT NULL DEFAULT 0,
+    "last_error"        TEXT NULL,
+    "created_at"        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "next_attempt_at"   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "published_at"      TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX "outbox_pending_idx" ON "outbox" ("next_attempt_at", "created_at") WHERE "published_at" IS NULL;
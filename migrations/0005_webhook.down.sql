@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "webhook_dead_letters";
+DROP INDEX IF EXISTS "webhook_deliveries_pending_idx";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_subscriptions";
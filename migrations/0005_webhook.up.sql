@@ -0,0 +1,38 @@
+CREATE TABLE "webhook_subscriptions" (
+    "id"            VARCHAR(20) PRIMARY KEY,
+    "url"           VARCHAR(2048) NOT NULL,
+    "secret"        VARCHAR(64) NOT NULL,
+    "event_types"   VARCHAR(100)[] NOT NULL,
+    "created_at"    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "deleted_at"    TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE TABLE "webhook_deliveries" (
+    "id"                VARCHAR(20) PRIMARY KEY,
+    "subscription_id"   VARCHAR(20) NOT NULL REFERENCES "webhook_subscriptions" ("id"),
+    "event_id"          VARCHAR(20) NOT NULL,
+    "event_type"        VARCHAR(100) NOT NULL,
+    "payload"           JSONB NOT NULL,
+    "status"            VARCHAR(20) NOT NULL,
+    "attempts"          INTEGER NOT NULL DEFAULT 0,
+    "response_status"   INTEGER NULL,
+    "last_error"        TEXT NULL,
+    "created_at"        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "next_attempt_at"   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "delivered_at"      TIMESTAMP WITH TIME ZONE NULL,
+    UNIQUE ("subscription_id", "event_id")
+);
+
+CREATE INDEX "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+CREATE TABLE "webhook_dead_letters" (
+    "id"                VARCHAR(20) PRIMARY KEY,
+    "delivery_id"       VARCHAR(20) NOT NULL REFERENCES "webhook_deliveries" ("id"),
+    "subscription_id"   VARCHAR(20) NOT NULL REFERENCES "webhook_subscriptions" ("id"),
+    "event_id"          VARCHAR(20) NOT NULL,
+    "event_type"        VARCHAR(100) NOT NULL,
+    "payload"           JSONB NOT NULL,
+    "attempts"          INTEGER NOT NULL,
+    "reason"            TEXT NOT NULL,
+    "created_at"        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
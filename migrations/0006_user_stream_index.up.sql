@@ -0,0 +1,3 @@
+-- migrate:no-transaction
+DROP INDEX CONCURRENTLY IF EXISTS "users_created_at_idx";
+CREATE INDEX CONCURRENTLY "users_created_at_idx" ON "users" ("created_at", "id") WHERE "deleted_at" IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
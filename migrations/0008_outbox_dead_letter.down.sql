@@ -0,0 +1,5 @@
+-- migrate:no-transaction
+DROP INDEX CONCURRENTLY IF EXISTS "outbox_pending_idx";
+CREATE INDEX CONCURRENTLY "outbox_pending_idx" ON "outbox" ("next_attempt_at", "created_at") WHERE "published_at" IS NULL;
+DROP INDEX CONCURRENTLY IF EXISTS "outbox_due_idx";
+ALTER TABLE "outbox" DROP COLUMN IF EXISTS "dead_lettered_at";
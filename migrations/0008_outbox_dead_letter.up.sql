@@ -0,0 +1,5 @@
+-- migrate:no-transaction
+ALTER TABLE "outbox" ADD COLUMN IF NOT EXISTS "dead_lettered_at" TIMESTAMP WITH TIME ZONE NULL;
+DROP INDEX CONCURRENTLY IF EXISTS "outbox_due_idx";
+CREATE INDEX CONCURRENTLY "outbox_due_idx" ON "outbox" ("next_attempt_at", "created_at") WHERE "published_at" IS NULL AND "dead_lettered_at" IS NULL;
+DROP INDEX CONCURRENTLY IF EXISTS "outbox_pending_idx";
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations of the database into the binary.
+package migrations
+
+import "embed"
+
+// FS contains the up and down SQL files of every migration.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,79 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/repository"
+)
+
+// RelayUseCase interacts with the event relay use case.
+type RelayUseCase interface {
+	Relay(ctx context.Context) (int, error)
+}
+
+// RelayConfiguration describes the Relay configuration.
+// Lease is how long claimed events wait for their publication outcome before being claimed again,
+// so it should be longer than publishing a whole batch.
+type RelayConfiguration struct {
+	BatchSize   int
+	MaxAttempts int
+	Lease       time.Duration
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Relay implements the RelayUseCase.
+type Relay struct {
+	outboxRepository repository.OutboxRepository
+	sink             Sink
+	configuration    RelayConfiguration
+}
+
+// NewRelay creates a new Relay instance.
+func NewRelay(outboxRepository repository.OutboxRepository, sink Sink, configuration RelayConfiguration) *Relay {
+	return &Relay{
+		outboxRepository: outboxRepository,
+		sink:             sink,
+		configuration:    configuration,
+	}
+}
+
+// Relay publishes a batch of pending events to the sink, returning how many were published.
+// Events are claimed beforehand and published without holding any lock or transaction, recording each
+// outcome on its own. Failed events are retried later with exponential backoff until MaxAttempts, then dead
+// lettered, and events whose outcome could not be recorded once the lease expires, so events are delivered
+// at least once.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	events, err := r.outboxRepository.Claim(ctx, r.configuration.BatchSize, time.Now().Add(r.configuration.Lease))
+	if err != nil {
+		return 0, errors.Wrap(err, "Cannot claim events")
+	}
+
+	published := 0
+
+	for i := range events {
+		event := &events[i]
+
+		if perr := r.sink.Publish(ctx, event); perr != nil {
+			if event.Attempts+1 < r.configuration.MaxAttempts {
+				delay := database.Backoff(event.Attempts+1, r.configuration.MinBackoff, r.configuration.MaxBackoff)
+				err = r.outboxRepository.MarkFailed(ctx, event.ID, perr.Error(), time.Now().Add(delay))
+			} else {
+				err = r.outboxRepository.MarkDead(ctx, event.ID, perr.Error())
+			}
+		} else {
+			err = r.outboxRepository.MarkPublished(ctx, event.ID)
+			published++
+		}
+
+		if err != nil {
+			return published, errors.Wrap(err, "Cannot relay events")
+		}
+	}
+
+	return published, nil
+}
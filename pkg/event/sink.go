@@ -0,0 +1,131 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/pkg/model"
+)
+
+// Sinks enumerates the possible sinks.
+var Sinks = struct {
+	WEBHOOK string
+	STDOUT  string
+	MEMORY  string
+}{"webhook", "stdout", "memory"}
+
+// Sink publishes events to an external destination.
+type Sink interface {
+	Publish(ctx context.Context, event *model.Event) error
+}
+
+// WebhookSink publishes events as JSON to an HTTP endpoint.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a new WebhookSink instance.
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: client,
+	}
+}
+
+// Publish posts the event to the webhook URL, failing on any non 2XX response.
+func (s *WebhookSink) Publish(ctx context.Context, event *model.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "Cannot marshal event")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "Cannot create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Cannot send webhook request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errors.Newf("Webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// StdoutSink publishes events as JSON lines to a writer.
+type StdoutSink struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+// NewStdoutSink creates a new StdoutSink instance.
+func NewStdoutSink(out io.Writer) *StdoutSink {
+	return &StdoutSink{
+		out: out,
+	}
+}
+
+// Publish writes the event to the writer.
+func (s *StdoutSink) Publish(ctx context.Context, event *model.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "Cannot marshal event")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := fmt.Fprintf(s.out, "%s\n", body); err != nil {
+		return errors.Wrap(err, "Cannot write event")
+	}
+
+	return nil
+}
+
+// MemorySink keeps the published events in memory, intended for tests.
+type MemorySink struct {
+	mutex  sync.Mutex
+	events []model.Event
+}
+
+// NewMemorySink creates a new MemorySink instance.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{
+		events: make([]model.Event, 0),
+	}
+}
+
+// Publish appends the event to the published ones.
+func (s *MemorySink) Publish(ctx context.Context, event *model.Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, *event)
+
+	return nil
+}
+
+// Events returns a copy of the published events.
+func (s *MemorySink) Events() []model.Event {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	events := make([]model.Event, len(s.events))
+	copy(events, s.events)
+
+	return events
+}
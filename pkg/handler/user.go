@@ -1,25 +1,56 @@
 package handler
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/neoxelox/zeus/internal/exception"
+	"github.com/neoxelox/zeus/pkg/model"
 	"github.com/neoxelox/zeus/pkg/payload"
 	"github.com/neoxelox/zeus/pkg/user"
 )
 
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+
+	importBatchSize = 1000
+	exportFlushSize = 100
+
+	// exportErrorTrailer is set in the trailers of an export that failed after being committed.
+	exportErrorTrailer = "Export-Error"
+)
+
 // UserHandler describes the user handler.
 type UserHandler struct {
-	userCreator user.CreatorUseCase
-	userGetter  user.GetterUseCase
+	userCreator  user.CreatorUseCase
+	userGetter   user.GetterUseCase
+	userUpdater  user.UpdaterUseCase
+	userDeleter  user.DeleterUseCase
+	userExporter user.ExporterUseCase
 }
 
 // NewUserHandler creates a new UserHandler instance.
-func NewUserHandler(userCreator user.CreatorUseCase, userGetter user.GetterUseCase) *UserHandler {
+func NewUserHandler(userCreator user.CreatorUseCase, userGetter user.GetterUseCase,
+	userUpdater user.UpdaterUseCase, userDeleter user.DeleterUseCase, userExporter user.ExporterUseCase) *UserHandler {
 	return &UserHandler{
-		userCreator: userCreator,
-		userGetter:  userGetter,
+		userCreator:  userCreator,
+		userGetter:   userGetter,
+		userUpdater:  userUpdater,
+		userDeleter:  userDeleter,
+		userExporter: userExporter,
 	}
 }
 
@@ -58,11 +89,67 @@ func (h *UserHandler) GetByID(ctx echo.Context) error {
 		return err // nolint
 	}
 
+	ctx.Response().Header().Set(headerETag, m.ETag())
+
+	if m.MatchETag(ctx.Request().Header.Get(headerIfNoneMatch)) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+
 	res := payload.NewUserGetByIDResponse(m)
 
 	return ctx.JSON(http.StatusOK, res)
 }
 
+// Update updates a user if it was not modified since the version given in the If-Match header.
+func (h *UserHandler) Update(ctx echo.Context) error {
+	var req payload.UserUpdateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot bind user update request")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot validate user update request")
+	}
+
+	version := strings.TrimSpace(ctx.Request().Header.Get(headerIfMatch))
+	if version == "" {
+		return payload.ErrPreconditionRequired.New("Cannot update user without If-Match header")
+	}
+
+	m, err := h.userUpdater.Update(ctx.Request().Context(), req.ID, version, req.Name, req.Username, req.Age)
+	if err != nil {
+		return err // nolint
+	}
+
+	ctx.Response().Header().Set(headerETag, m.ETag())
+
+	res := payload.NewUserUpdateResponse(m)
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// Delete deletes a user if it was not modified since the version given in the If-Match header.
+func (h *UserHandler) Delete(ctx echo.Context) error {
+	var req payload.UserDeleteRequest
+	if err := ctx.Bind(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot bind user delete request")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot validate user delete request")
+	}
+
+	version := strings.TrimSpace(ctx.Request().Header.Get(headerIfMatch))
+	if version == "" {
+		return payload.ErrPreconditionRequired.New("Cannot delete user without If-Match header")
+	}
+
+	err := h.userDeleter.Delete(ctx.Request().Context(), req.ID, version)
+	if err != nil {
+		return err // nolint
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
 // List gets existing users with a similar username.
 func (h *UserHandler) List(ctx echo.Context) error {
 	var req payload.UserListRequest
@@ -82,3 +169,347 @@ func (h *UserHandler) List(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, res)
 }
+
+// Export streams all users as CSV or NDJSON depending on the Accept header.
+// The status is only sent once the first user is read, so that errors before it are returned as such.
+// Errors after it are signaled in the exportErrorTrailer, as the response is already committed.
+func (h *UserHandler) Export(ctx echo.Context) error {
+	format, err := negotiateFormat(ctx.Request().Header.Get(echo.HeaderAccept))
+	if err != nil {
+		return err
+	}
+
+	res := ctx.Response()
+
+	var header func() error
+	var encode func(*payload.UserExportRecord) error
+	var flush func() error
+
+	switch format {
+	case payload.Formats.CSV:
+		w := csv.NewWriter(res)
+
+		header = func() error { return w.Write(payload.UserCSVHeader) }
+		encode = func(r *payload.UserExportRecord) error { return w.Write(r.CSV()) }
+		flush = func() error {
+			w.Flush()
+
+			return w.Error()
+		}
+	default:
+		enc := json.NewEncoder(res)
+
+		header = func() error { return nil }
+		encode = func(r *payload.UserExportRecord) error { return enc.Encode(r) }
+		flush = func() error { return nil }
+	}
+
+	rows := 0
+
+	start := func() error {
+		res.Header().Set(echo.HeaderContentType, format)
+		res.Header().Set(echo.HeaderContentDisposition, "attachment")
+		res.Header().Set("Trailer", exportErrorTrailer)
+		res.WriteHeader(http.StatusOK)
+
+		return header()
+	}
+
+	write := func(m *model.User) error {
+		if rows == 0 {
+			if werr := start(); werr != nil {
+				return werr
+			}
+		}
+
+		if werr := encode(payload.NewUserExportRecord(m)); werr != nil {
+			return werr // nolint
+		}
+
+		rows++
+
+		if rows%exportFlushSize == 0 {
+			if werr := flush(); werr != nil {
+				return werr
+			}
+			res.Flush()
+		}
+
+		return nil
+	}
+
+	err = h.userExporter.Export(ctx.Request().Context(), write)
+
+	switch {
+	case err != nil && !res.Committed:
+		return err
+	case err != nil:
+		flush() // nolint
+		res.Header().Set(exportErrorTrailer, exception.ErrGeneric.Message)
+		res.Flush()
+
+		return errors.Wrapf(err, "Cannot export users after %d rows", rows)
+	case rows == 0:
+		if err = start(); err != nil {
+			return errors.Wrap(err, "Cannot write user export")
+		}
+	}
+
+	if err = flush(); err != nil {
+		return errors.Wrap(err, "Cannot write user export")
+	}
+	res.Flush()
+
+	return nil
+}
+
+// Import creates the users streamed as CSV or NDJSON, reporting the lines that could not be imported.
+// Users are created in bulk batches, retrying them one by one only if their batch fails.
+func (h *UserHandler) Import(ctx echo.Context) error {
+	req := ctx.Request()
+
+	var next func() (*payload.UserImportRecord, int, error)
+
+	switch mediaType(req.Header.Get(echo.HeaderContentType)) {
+	case payload.Formats.CSV:
+		var err error
+
+		next, err = csvRecords(req.Body)
+		if err != nil {
+			return err
+		}
+	case payload.Formats.NDJSON:
+		next = ndjsonRecords(req.Body)
+	default:
+		return payload.ErrUnsupportedMediaType.New("Cannot import users from the given content type")
+	}
+
+	created := 0
+	errs := []payload.UserImportError{}
+
+	report := func(line int, err error) error {
+		var exc exception.Exception
+
+		switch {
+		case err == nil:
+			created++
+		case errors.As(err, &exc):
+			errs = append(errs, payload.UserImportError{Line: line, Message: exc.Message})
+		default:
+			return errors.Wrapf(err, "Cannot import user at line %d", line)
+		}
+
+		return nil
+	}
+
+	lines := make([]int, 0, importBatchSize)
+	users := make([]*model.User, 0, importBatchSize)
+
+	flush := func() error {
+		defer func() {
+			lines = lines[:0]
+			users = users[:0]
+		}()
+
+		if len(users) == 0 {
+			return nil
+		}
+
+		err := h.userCreator.CreateMany(req.Context(), users)
+		if err == nil {
+			created += len(users)
+
+			return nil
+		}
+
+		var exc exception.Exception
+		if !errors.As(err, &exc) {
+			return errors.Wrapf(err, "Cannot import users from line %d", lines[0])
+		}
+
+		for i, u := range users {
+			_, err = h.userCreator.Create(req.Context(), u.Name, u.Username, u.Age)
+			if err = report(lines[i], err); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for {
+		rec, line, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err == nil {
+			err = ctx.Validate(rec)
+			if err != nil {
+				err = payload.ErrInvalidRequest.Wrap(err, "Cannot validate user import record")
+			}
+		}
+
+		if err != nil {
+			if err = report(line, err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		lines = append(lines, line)
+		users = append(users, model.NewUser(rec.Name, rec.Username, rec.Age))
+
+		if len(users) == importBatchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+
+	res := payload.NewUserImportResponse(created, errs)
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func negotiateFormat(accept string) (string, error) {
+	if accept == "" {
+		return payload.Formats.NDJSON, nil
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		switch mediaType(part) {
+		case payload.Formats.CSV:
+			return payload.Formats.CSV, nil
+		case payload.Formats.NDJSON, "application/ndjson", "*/*", "application/*":
+			return payload.Formats.NDJSON, nil
+		}
+	}
+
+	return "", payload.ErrNotAcceptable.New("Cannot export users in the accepted formats")
+}
+
+func mediaType(header string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(header, ";")[0]))
+}
+
+// csvRecords returns an iterator over the records of a CSV body whose first row is the header,
+// along with the line each record starts at, as quoted fields may span several lines.
+// Malformed rows are reported as invalid requests without stopping the iteration, while a missing or malformed
+// header, lacking the name or username columns, fails the whole import as an invalid request.
+func csvRecords(body io.Reader) (func() (*payload.UserImportRecord, int, error), error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, payload.ErrInvalidRequest.Wrap(err, "Cannot read user import header")
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range []string{"name", "username"} {
+		if _, ok := columns[column]; !ok {
+			return nil, payload.ErrInvalidRequest.With("column", column).New("Cannot import users without a header column")
+		}
+	}
+
+	line := 1 + csvLines(header)
+
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+
+	return func() (*payload.UserImportRecord, int, error) {
+		start := line
+
+		record, err := r.Read()
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				line = perr.Line + 1
+
+				return nil, perr.StartLine, payload.ErrInvalidRequest.Wrap(err, "Cannot parse user import record")
+			}
+
+			return nil, start, err // nolint
+		}
+
+		line += csvLines(record)
+
+		rec := &payload.UserImportRecord{
+			Name:     field(record, "name"),
+			Username: field(record, "username"),
+		}
+
+		if age := field(record, "age"); age != "" {
+			rec.Age, err = strconv.Atoi(age)
+			if err != nil {
+				return nil, start, payload.ErrInvalidRequest.Wrap(err, "Cannot parse user import record age")
+			}
+		}
+
+		return rec, start, nil
+	}, nil
+}
+
+// csvLines counts the physical lines a record spans, one plus the newlines within its quoted fields.
+func csvLines(record []string) int {
+	lines := 1
+	for _, field := range record {
+		lines += strings.Count(field, "\n")
+	}
+
+	return lines
+}
+
+// ndjsonRecords returns an iterator over the records of a newline delimited JSON body, along with their line,
+// skipping blank lines.
+// Malformed lines are reported as invalid requests without stopping the iteration.
+func ndjsonRecords(body io.Reader) func() (*payload.UserImportRecord, int, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // nolint
+
+	line := 0
+
+	return func() (*payload.UserImportRecord, int, error) {
+		for {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, line + 1, err // nolint
+				}
+
+				return nil, line + 1, io.EOF
+			}
+
+			line++
+
+			// Blank lines, such as a trailing one, separate no record.
+			if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+				break
+			}
+		}
+
+		var rec payload.UserImportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, line, payload.ErrInvalidRequest.Wrap(err, "Cannot parse user import record")
+		}
+
+		return &rec, line, nil
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/neoxelox/zeus/pkg/payload"
+	"github.com/neoxelox/zeus/pkg/webhook"
+)
+
+// WebhookHandler describes the webhook handler.
+type WebhookHandler struct {
+	webhookSubscriber webhook.SubscriberUseCase
+}
+
+// NewWebhookHandler creates a new WebhookHandler instance.
+func NewWebhookHandler(webhookSubscriber webhook.SubscriberUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookSubscriber: webhookSubscriber,
+	}
+}
+
+// Create registers a new webhook subscription.
+func (h *WebhookHandler) Create(ctx echo.Context) error {
+	var req payload.WebhookCreateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot bind webhook create request")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot validate webhook create request")
+	}
+
+	m, err := h.webhookSubscriber.Subscribe(ctx.Request().Context(), req.URL, req.EventTypes)
+	if err != nil {
+		return err // nolint
+	}
+
+	res := payload.NewWebhookCreateResponse(m)
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// List gets the existing webhook subscriptions.
+func (h *WebhookHandler) List(ctx echo.Context) error {
+	ms, err := h.webhookSubscriber.List(ctx.Request().Context())
+	if err != nil {
+		return err // nolint
+	}
+
+	res := payload.NewWebhookListResponse(ms)
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// Delete deletes a webhook subscription.
+func (h *WebhookHandler) Delete(ctx echo.Context) error {
+	var req payload.WebhookDeleteRequest
+	if err := ctx.Bind(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot bind webhook delete request")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot validate webhook delete request")
+	}
+
+	err := h.webhookSubscriber.Unsubscribe(ctx.Request().Context(), req.ID)
+	if err != nil {
+		return err // nolint
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ListDeliveries gets the delivery history of a webhook subscription.
+func (h *WebhookHandler) ListDeliveries(ctx echo.Context) error {
+	var req payload.WebhookListDeliveriesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot bind webhook list deliveries request")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return payload.ErrInvalidRequest.Wrap(err, "Cannot validate webhook list deliveries request")
+	}
+
+	ms, err := h.webhookSubscriber.ListDeliveries(ctx.Request().Context(), req.ID)
+	if err != nil {
+		return err // nolint
+	}
+
+	res := payload.NewWebhookListDeliveriesResponse(ms)
+
+	return ctx.JSON(http.StatusOK, res)
+}
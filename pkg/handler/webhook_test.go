@@ -0,0 +1 @@
+package handler_test
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// EventTypes enumerates the possible event types.
+var EventTypes = struct {
+	UserCreated string
+	UserUpdated string
+	UserDeleted string
+}{"user.created", "user.updated", "user.deleted"}
+
+// Event represents a domain event waiting in the outbox to be published.
+type Event struct {
+	ID             xid.ID          `json:"id" db:"id"`
+	Type           string          `json:"type" db:"type"`
+	AggregateID    xid.ID          `json:"aggregate_id" db:"aggregate_id"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Attempts       int             `json:"-" db:"attempts"`
+	LastError      *string         `json:"-" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	NextAttemptAt  time.Time       `json:"-" db:"next_attempt_at"`
+	PublishedAt    *time.Time      `json:"-" db:"published_at"`
+	DeadLetteredAt *time.Time      `json:"-" db:"dead_lettered_at"`
+}
+
+// NewEvent creates a new Event instance.
+func NewEvent(eventType string, aggregateID xid.ID, payload interface{}) (*Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err // nolint
+	}
+
+	now := time.Now()
+
+	return &Event{
+		ID:            xid.New(),
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		Payload:       raw,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// UserEventPayload describes the user of a user lifecycle event, including its timestamps.
+type UserEventPayload struct {
+	ID        xid.ID     `json:"id"`
+	Name      string     `json:"name"`
+	Username  string     `json:"username"`
+	Age       int        `json:"age"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+// NewUserEventPayload creates a new UserEventPayload instance.
+func NewUserEventPayload(user *User) *UserEventPayload {
+	return &UserEventPayload{
+		ID:        user.ID,
+		Name:      user.Name,
+		Username:  user.Username,
+		Age:       user.Age,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		DeletedAt: user.DeletedAt,
+	}
+}
@@ -1,7 +1,9 @@
 package model
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rs/xid"
@@ -18,11 +20,13 @@ type User struct {
 	CreatedAt time.Time  `json:"-" db:"created_at"`
 	UpdatedAt time.Time  `json:"-" db:"updated_at"`
 	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+	Version   int        `json:"-" db:"version"`
 }
 
 // NewUser creates a new User instance.
 func NewUser(name string, username string, age int) *User {
-	now := time.Now()
+	// Timestamps are stored with microsecond precision, so that events and ETags match the stored user.
+	now := time.Now().Truncate(time.Microsecond)
 
 	return &User{
 		ID:        xid.New(),
@@ -31,19 +35,47 @@ func NewUser(name string, username string, age int) *User {
 		Age:       age,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Version:   1,
 	}
 }
 
+// ETag returns the entity tag of the current user version.
+func (u User) ETag() string {
+	return fmt.Sprintf(`"%s.%d"`, u.ID, u.Version)
+}
+
+// MatchETag checks whether the If-Match or If-None-Match header, either * or a list of entity tags,
+// matches the current user version using weak comparison.
+func (u User) MatchETag(header string) bool {
+	etag := u.ETag()
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
 // UserMinAge minimum age for user to exist.
 const UserMinAge = 18
 
 var (
 	// ErrUserBelowAge user is below UserMinAge.
-	ErrUserBelowAge = exception.New(http.StatusBadRequest, "ERR_USER_BELOW_AGE")
+	ErrUserBelowAge = exception.New(http.StatusBadRequest, "ERR_USER_BELOW_AGE",
+		"The user is below the minimum age.")
 
 	// ErrExistingUsername username already exists.
-	ErrExistingUsername = exception.New(http.StatusBadRequest, "ERR_EXISTING_USERNAME")
+	ErrExistingUsername = exception.New(http.StatusBadRequest, "ERR_EXISTING_USERNAME",
+		"The username is already taken by another user.")
 
 	// ErrUserNotExists user not exists.
-	ErrUserNotExists = exception.New(http.StatusBadRequest, "ERR_USER_NOT_EXISTS")
+	ErrUserNotExists = exception.New(http.StatusBadRequest, "ERR_USER_NOT_EXISTS",
+		"The user does not exist.")
+
+	// ErrUserVersionMismatch user was modified since the given version.
+	ErrUserVersionMismatch = exception.New(http.StatusPreconditionFailed, "ERR_USER_VERSION_MISMATCH",
+		"The user was modified since the version given in the If-Match header.")
 )
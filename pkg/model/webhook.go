@@ -0,0 +1,119 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/neoxelox/zeus/internal/exception"
+)
+
+// WebhookDeliveryStatuses enumerates the possible webhook delivery statuses.
+var WebhookDeliveryStatuses = struct {
+	PENDING   string
+	DELIVERED string
+	FAILED    string
+}{"pending", "delivered", "failed"}
+
+// WebhookSubscription represents a partner endpoint subscribed to event types.
+type WebhookSubscription struct {
+	ID         xid.ID     `json:"id" db:"id"`
+	URL        string     `json:"url" db:"url"`
+	Secret     string     `json:"-" db:"secret"`
+	EventTypes []string   `json:"event_types" db:"event_types"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	DeletedAt  *time.Time `json:"-" db:"deleted_at"`
+}
+
+// NewWebhookSubscription creates a new WebhookSubscription instance with a random signing secret.
+func NewWebhookSubscription(url string, eventTypes []string) (*WebhookSubscription, error) {
+	secret := make([]byte, 32) // nolint
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err // nolint
+	}
+
+	return &WebhookSubscription{
+		ID:         xid.New(),
+		URL:        url,
+		Secret:     hex.EncodeToString(secret),
+		EventTypes: eventTypes,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// WebhookDelivery represents an event to be delivered to a webhook subscription.
+type WebhookDelivery struct {
+	ID             xid.ID          `json:"id" db:"id"`
+	SubscriptionID xid.ID          `json:"subscription_id" db:"subscription_id"`
+	EventID        xid.ID          `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"-" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"response_status" db:"response_status"`
+	LastError      *string         `json:"last_error" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at" db:"delivered_at"`
+}
+
+// NewWebhookDelivery creates a new pending WebhookDelivery instance of the event.
+func NewWebhookDelivery(subscriptionID xid.ID, event *Event) (*WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err // nolint
+	}
+
+	now := time.Now()
+
+	return &WebhookDelivery{
+		ID:             xid.New(),
+		SubscriptionID: subscriptionID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        payload,
+		Status:         WebhookDeliveryStatuses.PENDING,
+		CreatedAt:      now,
+		NextAttemptAt:  now,
+	}, nil
+}
+
+// WebhookDeadLetter represents a webhook delivery that exhausted its attempts.
+type WebhookDeadLetter struct {
+	ID             xid.ID          `json:"id" db:"id"`
+	DeliveryID     xid.ID          `json:"delivery_id" db:"delivery_id"`
+	SubscriptionID xid.ID          `json:"subscription_id" db:"subscription_id"`
+	EventID        xid.ID          `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"-" db:"payload"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	Reason         string          `json:"reason" db:"reason"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// NewWebhookDeadLetter creates a new WebhookDeadLetter instance of the delivery.
+func NewWebhookDeadLetter(delivery *WebhookDelivery, reason string) *WebhookDeadLetter {
+	return &WebhookDeadLetter{
+		ID:             xid.New(),
+		DeliveryID:     delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Attempts:       delivery.Attempts,
+		Reason:         reason,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// ErrWebhookNotExists webhook subscription not exists.
+var ErrWebhookNotExists = exception.New(http.StatusBadRequest, "ERR_WEBHOOK_NOT_EXISTS",
+	"The webhook subscription does not exist.")
+
+// ErrWebhookInvalidURL webhook URL not HTTPS or not public.
+var ErrWebhookInvalidURL = exception.New(http.StatusBadRequest, "ERR_WEBHOOK_INVALID_URL",
+	"The webhook URL must use HTTPS and point to a public host.")
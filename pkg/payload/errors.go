@@ -6,5 +6,32 @@ import (
 	"github.com/neoxelox/zeus/internal/exception"
 )
 
-// ErrInvalidRequest invalid headers, parameters or body for request.
-var ErrInvalidRequest = exception.New(http.StatusBadRequest, "ERR_INVALID_REQUEST")
+var (
+	// ErrInvalidRequest invalid headers, parameters or body for request.
+	ErrInvalidRequest = exception.New(http.StatusBadRequest, "ERR_INVALID_REQUEST",
+		"The headers, parameters or body of the request are invalid.")
+
+	// ErrPreconditionRequired conditional header required for request is missing.
+	ErrPreconditionRequired = exception.New(http.StatusPreconditionRequired, "ERR_PRECONDITION_REQUIRED",
+		"A conditional header required for the request is missing.")
+
+	// ErrNotAcceptable none of the formats in the Accept header can be produced.
+	ErrNotAcceptable = exception.New(http.StatusNotAcceptable, "ERR_NOT_ACCEPTABLE",
+		"None of the formats in the Accept header can be produced.")
+
+	// ErrUnsupportedMediaType the Content-Type of the request body cannot be consumed.
+	ErrUnsupportedMediaType = exception.New(http.StatusUnsupportedMediaType, "ERR_UNSUPPORTED_MEDIA_TYPE",
+		"The Content-Type of the request body cannot be consumed.")
+)
+
+// ErrorListResponse describes the catalog of the errors the API may return.
+type ErrorListResponse struct {
+	Errors []exception.Entry `json:"errors"`
+}
+
+// NewErrorListResponse creates a new ErrorListResponse instance.
+func NewErrorListResponse(entries []exception.Entry) *ErrorListResponse {
+	return &ErrorListResponse{
+		Errors: entries,
+	}
+}
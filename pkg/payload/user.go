@@ -1,6 +1,9 @@
 package payload
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/rs/xid"
 
 	"github.com/neoxelox/zeus/pkg/model"
@@ -46,6 +49,33 @@ func NewUserGetByIDResponse(m *model.User) *UserGetByIDResponse {
 	}
 }
 
+type (
+	// UserUpdateRequest describes the user update request.
+	UserUpdateRequest struct {
+		ID       xid.ID `param:"id" validate:"required"`
+		Name     string `json:"name" validate:"required"`
+		Username string `json:"username" validate:"required"`
+		Age      int    `json:"age" validate:"required"`
+	}
+
+	// UserUpdateResponse describes the user update response.
+	UserUpdateResponse struct {
+		User model.User `json:"user"`
+	}
+)
+
+// NewUserUpdateResponse creates a new UserUpdateResponse instance.
+func NewUserUpdateResponse(m *model.User) *UserUpdateResponse {
+	return &UserUpdateResponse{
+		User: *m,
+	}
+}
+
+// UserDeleteRequest describes the user delete request.
+type UserDeleteRequest struct {
+	ID xid.ID `param:"id" validate:"required"`
+}
+
 type (
 	// UserListRequest describes the user list request.
 	UserListRequest struct {
@@ -68,3 +98,77 @@ func NewUserListResponse(ms []model.User) *UserListResponse {
 		Users: ms,
 	}
 }
+
+// Formats enumerates the possible streaming formats.
+var Formats = struct {
+	CSV    string
+	NDJSON string
+}{"text/csv", "application/x-ndjson"}
+
+// UserExportRecord describes a single user of an export, in any of the Formats.
+type UserExportRecord struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Username  string `json:"username"`
+	Age       int    `json:"age"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// UserCSVHeader describes the columns of a user CSV export.
+var UserCSVHeader = []string{"id", "name", "username", "age", "created_at", "updated_at"}
+
+// NewUserExportRecord creates a new UserExportRecord instance.
+func NewUserExportRecord(m *model.User) *UserExportRecord {
+	return &UserExportRecord{
+		ID:        m.ID.String(),
+		Name:      m.Name,
+		Username:  m.Username,
+		Age:       m.Age,
+		CreatedAt: m.CreatedAt.UTC().Format(time.RFC3339Nano),
+		UpdatedAt: m.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// CSV returns the record as a CSV row.
+func (r *UserExportRecord) CSV() []string {
+	return []string{r.ID, r.Name, r.Username, strconv.Itoa(r.Age), r.CreatedAt, r.UpdatedAt}
+}
+
+type (
+	// UserImportRecord describes a single user of an import request or a seed fixture.
+	UserImportRecord struct {
+		Name     string `json:"name" yaml:"name" validate:"required"`
+		Username string `json:"username" yaml:"username" validate:"required"`
+		Age      int    `json:"age" yaml:"age" validate:"required"`
+	}
+
+	// UserSeedFixture describes a fixture file of users to seed.
+	UserSeedFixture struct {
+		Users []UserImportRecord `json:"users" yaml:"users" validate:"dive"`
+	}
+
+	// UserImportError describes a line of an import request that could not be imported.
+	UserImportError struct {
+		Line    int    `json:"line"`
+		Message string `json:"message"`
+	}
+
+	// UserImportResponse describes the user import response.
+	UserImportResponse struct {
+		Created int               `json:"created"`
+		Errors  []UserImportError `json:"errors"`
+	}
+)
+
+// NewUserImportResponse creates a new UserImportResponse instance.
+func NewUserImportResponse(created int, errs []UserImportError) *UserImportResponse {
+	if len(errs) == 0 {
+		errs = make([]UserImportError, 0)
+	}
+
+	return &UserImportResponse{
+		Created: created,
+		Errors:  errs,
+	}
+}
@@ -0,0 +1,74 @@
+package payload
+
+import (
+	"github.com/rs/xid"
+
+	"github.com/neoxelox/zeus/pkg/model"
+)
+
+type (
+	// WebhookCreateRequest describes the webhook create request.
+	WebhookCreateRequest struct {
+		URL        string   `json:"url" validate:"required,url"`
+		EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=user.created user.updated user.deleted"` // nolint
+	}
+
+	// WebhookCreateResponse describes the webhook create response.
+	// It is the only response that discloses the signing secret.
+	WebhookCreateResponse struct {
+		Webhook model.WebhookSubscription `json:"webhook"`
+		Secret  string                    `json:"secret"`
+	}
+)
+
+// NewWebhookCreateResponse creates a new WebhookCreateResponse instance.
+func NewWebhookCreateResponse(m *model.WebhookSubscription) *WebhookCreateResponse {
+	return &WebhookCreateResponse{
+		Webhook: *m,
+		Secret:  m.Secret,
+	}
+}
+
+// WebhookListResponse describes the webhook list response.
+type WebhookListResponse struct {
+	Webhooks []model.WebhookSubscription `json:"webhooks"`
+}
+
+// NewWebhookListResponse creates a new WebhookListResponse instance.
+func NewWebhookListResponse(ms []model.WebhookSubscription) *WebhookListResponse {
+	if len(ms) == 0 {
+		ms = make([]model.WebhookSubscription, 0)
+	}
+
+	return &WebhookListResponse{
+		Webhooks: ms,
+	}
+}
+
+// WebhookDeleteRequest describes the webhook delete request.
+type WebhookDeleteRequest struct {
+	ID xid.ID `param:"id" validate:"required"`
+}
+
+type (
+	// WebhookListDeliveriesRequest describes the webhook list deliveries request.
+	WebhookListDeliveriesRequest struct {
+		ID xid.ID `param:"id" validate:"required"`
+	}
+
+	// WebhookListDeliveriesResponse describes the webhook list deliveries response.
+	WebhookListDeliveriesResponse struct {
+		Deliveries []model.WebhookDelivery `json:"deliveries"`
+	}
+)
+
+// NewWebhookListDeliveriesResponse creates a new WebhookListDeliveriesResponse instance.
+func NewWebhookListDeliveriesResponse(ms []model.WebhookDelivery) *WebhookListDeliveriesResponse {
+	if len(ms) == 0 {
+		ms = make([]model.WebhookDelivery, 0)
+	}
+
+	return &WebhookListDeliveriesResponse{
+		Deliveries: ms,
+	}
+}
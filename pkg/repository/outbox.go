@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/rs/xid"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+)
+
+// Tables of the OutboxDatabase.
+const (
+	outboxTable = "outbox"
+)
+
+// outboxColumns are the columns of the OutboxDatabase, which its statements select explicitly so that
+// adding a column does not change the result type of the statements prepared on open connections.
+const outboxColumns = `"id", "type", "aggregate_id", "payload", "attempts", "last_error", "created_at", ` +
+	`"next_attempt_at", "published_at", "dead_lettered_at"`
+
+// Statements of the OutboxDatabase, named after their table and method.
+const (
+	outboxCreateStatement        = "outbox.create"
+	outboxClaimStatement         = "outbox.claim"
+	outboxMarkPublishedStatement = "outbox.mark_published"
+	outboxMarkFailedStatement    = "outbox.mark_failed"
+	outboxMarkDeadStatement      = "outbox.mark_dead"
+)
+
+// OutboxRepository interacts with the outbox repository.
+type OutboxRepository interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error, options ...database.TransactionOption) error
+	Create(ctx context.Context, m *model.Event) (*model.Event, error)
+	CreateMany(ctx context.Context, ms []*model.Event) error
+	Claim(ctx context.Context, limit int, until time.Time) ([]model.Event, error)
+	MarkPublished(ctx context.Context, ID xid.ID) error
+	MarkFailed(ctx context.Context, ID xid.ID, reason string, nextAttemptAt time.Time) error
+	MarkDead(ctx context.Context, ID xid.ID, reason string) error
+}
+
+// OutboxDatabase implements a SQL OutboxRepository.
+type OutboxDatabase struct {
+	db    *database.Database
+	cn    database.Connection
+	table string
+}
+
+// NewOutboxDatabase creates a new OutboxDatabase instance.
+func NewOutboxDatabase(db *database.Database) *OutboxDatabase {
+	return &OutboxDatabase{
+		db:    db,
+		cn:    db.Writer(),
+		table: outboxTable,
+	}
+}
+
+// OutboxStatements returns the statements of the OutboxDatabase, to be prepared on every database connection.
+func OutboxStatements() []database.Statement {
+	return []database.Statement{
+		{Name: outboxCreateStatement, SQL: fmt.Sprintf(
+			`INSERT INTO "%[1]s" ("id", "type", "aggregate_id", "payload", "created_at", "next_attempt_at")
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 RETURNING %[2]s;`, outboxTable, outboxColumns)},
+		{Name: outboxClaimStatement, SQL: fmt.Sprintf(
+			`WITH "claimed" AS (
+				 SELECT "id" AS "claimed_id" FROM "%[1]s"
+				 WHERE "published_at" IS NULL AND "dead_lettered_at" IS NULL AND "next_attempt_at" <= NOW()
+				 ORDER BY "created_at"
+				 LIMIT $1
+				 FOR UPDATE SKIP LOCKED)
+			 UPDATE "%[1]s" SET "next_attempt_at" = $2
+			 FROM "claimed" WHERE "id" = "claimed_id"
+			 RETURNING %[2]s;`, outboxTable, outboxColumns)},
+		{Name: outboxMarkPublishedStatement, SQL: fmt.Sprintf(
+			`UPDATE "%s"
+			 SET "attempts" = "attempts" + 1, "last_error" = NULL, "published_at" = NOW()
+			 WHERE "id" = $1;`, outboxTable)},
+		{Name: outboxMarkFailedStatement, SQL: fmt.Sprintf(
+			`UPDATE "%s"
+			 SET "attempts" = "attempts" + 1, "last_error" = $2, "next_attempt_at" = $3
+			 WHERE "id" = $1;`, outboxTable)},
+		{Name: outboxMarkDeadStatement, SQL: fmt.Sprintf(
+			`UPDATE "%s"
+			 SET "attempts" = "attempts" + 1, "last_error" = $2, "dead_lettered_at" = NOW()
+			 WHERE "id" = $1;`, outboxTable)},
+	}
+}
+
+// Transaction runs fn within a transaction joined by every repository called with its context.
+func (r *OutboxDatabase) Transaction(ctx context.Context, fn func(ctx context.Context) error,
+	options ...database.TransactionOption) error {
+	return r.db.Transaction(ctx, fn, options...)
+}
+
+// Create creates a new event in the outbox.
+func (r *OutboxDatabase) Create(ctx context.Context, m *model.Event) (*model.Event, error) {
+	var e model.Event
+
+	err := pgxutil.SelectStruct(ctx, r.cn, &e, outboxCreateStatement,
+		m.ID, m.Type, m.AggregateID, m.Payload, m.CreatedAt, m.NextAttemptAt)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return &e, nil
+}
+
+// CreateMany creates new events in the outbox pipelining their inserts in a single batch.
+func (r *OutboxDatabase) CreateMany(ctx context.Context, ms []*model.Event) error {
+	batch := &pgx.Batch{}
+
+	for _, m := range ms {
+		batch.Queue(outboxCreateStatement,
+			m.ID, m.Type, m.AggregateID, m.Payload, m.CreatedAt, m.NextAttemptAt)
+	}
+
+	return database.ExecBatch(ctx, r.cn, batch)
+}
+
+// Claim gets the oldest unpublished events that are due to be attempted, postponing their next attempt
+// until the given time so that no one else claims them meanwhile, sorted by creation.
+// Events being claimed by someone else or dead lettered are skipped.
+func (r *OutboxDatabase) Claim(ctx context.Context, limit int, until time.Time) ([]model.Event, error) {
+	var es []model.Event
+
+	err := pgxutil.SelectAllStruct(ctx, r.cn, &es, outboxClaimStatement,
+		limit, until)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	sort.Slice(es, func(i, j int) bool { return es[i].CreatedAt.Before(es[j].CreatedAt) })
+
+	return es, nil
+}
+
+// MarkPublished marks an event as published.
+func (r *OutboxDatabase) MarkPublished(ctx context.Context, ID xid.ID) error {
+	_, err := r.cn.Exec(ctx, outboxMarkPublishedStatement,
+		ID)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed publication attempt of an event and schedules the next one.
+func (r *OutboxDatabase) MarkFailed(ctx context.Context, ID xid.ID, reason string, nextAttemptAt time.Time) error {
+	_, err := r.cn.Exec(ctx, outboxMarkFailedStatement,
+		ID, reason, nextAttemptAt)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// MarkDead records the last failed publication attempt of an event and dead letters it,
+// so that it is not claimed anymore and can be inspected and replayed manually.
+func (r *OutboxDatabase) MarkDead(ctx context.Context, ID xid.ID, reason string) error {
+	_, err := r.cn.Exec(ctx, outboxMarkDeadStatement,
+		ID, reason)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	return nil
+}
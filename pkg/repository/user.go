@@ -3,8 +3,8 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/jackc/pgxutil"
 	"github.com/rs/xid"
 
@@ -12,57 +12,101 @@ import (
 	"github.com/neoxelox/zeus/pkg/model"
 )
 
+// UserUsernameConstraint unique constraint of the user username.
+const UserUsernameConstraint = "users_username_key"
+
+// Tables of the UserDatabase.
+const (
+	userTable = "users"
+)
+
+// userColumns are the columns of the UserDatabase, which its statements select explicitly so that
+// adding a column does not change the result type of the statements prepared on open connections.
+const userColumns = `"id", "name", "username", "age", "created_at", "updated_at", "deleted_at", "version"`
+
+// Statements of the UserDatabase, named after their table and method.
+const (
+	userCreateStatement  = "users.create"
+	userGetByIDStatement = "users.get_by_id"
+	userUpdateStatement  = "users.update"
+	userDeleteStatement  = "users.delete"
+	userListStatement    = "users.list"
+	userStreamStatement  = "users.stream"
+)
+
 // UserRepository interacts with the user repository.
 type UserRepository interface {
-	Transaction(ctx context.Context, fn func(UserRepository) error) error
+	Transaction(ctx context.Context, fn func(ctx context.Context) error, options ...database.TransactionOption) error
 	Create(ctx context.Context, m *model.User) (*model.User, error)
+	CreateMany(ctx context.Context, ms []*model.User) error
 	GetByID(ctx context.Context, ID xid.ID) (*model.User, error)
+	Update(ctx context.Context, m *model.User) (*model.User, error)
+	Delete(ctx context.Context, m *model.User) (*model.User, error)
 	List(ctx context.Context, username string) ([]model.User, error)
+	Stream(ctx context.Context, fn func(*model.User) error) error
+	Truncate(ctx context.Context) error
 }
 
 // UserDatabase implements a SQL UserRepository.
 type UserDatabase struct {
-	db    *pgxpool.Pool
+	db    *database.Database
 	cn    database.Connection
+	rd    database.Connection
 	table string
 }
 
 // NewUserDatabase creates a new UserDatabase instance.
-func NewUserDatabase(db *pgxpool.Pool) *UserDatabase {
+func NewUserDatabase(db *database.Database) *UserDatabase {
 	return &UserDatabase{
 		db:    db,
-		cn:    db,
-		table: "users",
+		cn:    db.Writer(),
+		rd:    db.Reader(),
+		table: userTable,
 	}
 }
 
-// Transaction returns a UserRepository for transactions.
-func (r *UserDatabase) Transaction(ctx context.Context, fn func(UserRepository) error) error {
-	tx, err := database.BeginTransaction(ctx, r.db)
-	if err != nil {
-		return err // nolint
+// UserStatements returns the statements of the UserDatabase, to be prepared on every database connection.
+func UserStatements() []database.Statement {
+	return []database.Statement{
+		{Name: userCreateStatement, SQL: fmt.Sprintf(
+			`INSERT INTO "%[1]s" (%[2]s)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			 RETURNING %[2]s;`, userTable, userColumns)},
+		{Name: userGetByIDStatement, SQL: fmt.Sprintf(
+			`SELECT %[2]s FROM "%[1]s" WHERE "id" = $1 AND "deleted_at" IS NULL;`, userTable, userColumns)},
+		{Name: userUpdateStatement, SQL: fmt.Sprintf(
+			`UPDATE "%[1]s"
+			 SET "name" = $2, "username" = $3, "age" = $4, "version" = "version" + 1, "updated_at" = clock_timestamp()
+			 WHERE "id" = $1 AND "version" = $5 AND "deleted_at" IS NULL
+			 RETURNING %[2]s;`, userTable, userColumns)},
+		{Name: userDeleteStatement, SQL: fmt.Sprintf(
+			`UPDATE "%[1]s"
+			 SET "version" = "version" + 1, "updated_at" = clock_timestamp(), "deleted_at" = clock_timestamp()
+			 WHERE "id" = $1 AND "version" = $2 AND "deleted_at" IS NULL
+			 RETURNING %[2]s;`, userTable, userColumns)},
+		{Name: userListStatement, SQL: fmt.Sprintf(
+			`SELECT %[2]s FROM "%[1]s"
+			 WHERE "username" LIKE '%%' || $1 || '%%' AND "deleted_at" IS NULL;`, userTable, userColumns)},
+		{Name: userStreamStatement, SQL: fmt.Sprintf(
+			`SELECT %[2]s
+			 FROM "%[1]s"
+			 WHERE "deleted_at" IS NULL
+			 ORDER BY "created_at", "id";`, userTable, userColumns)},
 	}
+}
 
-	defer database.WatchTransaction(ctx, tx)()
-
-	err = fn(&UserDatabase{
-		db: r.db,
-		cn: tx,
-	})
-
-	return database.FinishTransaction(ctx, err, tx)
+// Transaction runs fn within a transaction joined by every repository called with its context.
+func (r *UserDatabase) Transaction(ctx context.Context, fn func(ctx context.Context) error,
+	options ...database.TransactionOption) error {
+	return r.db.Transaction(ctx, fn, options...)
 }
 
 // Create creates a new user in the database.
 func (r *UserDatabase) Create(ctx context.Context, m *model.User) (*model.User, error) {
 	var u model.User
 
-	query := fmt.Sprintf(`INSERT INTO "%s" ("id", "name", "username", "age", "created_at", "updated_at", "deleted_at")
-			  			  VALUES ($1, $2, $3, $4, $5, $6, $7)
-			  			  RETURNING *;`, r.table)
-
-	err := pgxutil.SelectStruct(ctx, r.cn, &u, query,
-		m.ID, m.Name, m.Username, m.Age, m.CreatedAt, m.UpdatedAt, m.DeletedAt)
+	err := pgxutil.SelectStruct(ctx, r.cn, &u, userCreateStatement,
+		m.ID, m.Name, m.Username, m.Age, m.CreatedAt, m.UpdatedAt, m.DeletedAt, m.Version)
 	if err != nil {
 		return nil, database.Error(err)
 	}
@@ -70,13 +114,31 @@ func (r *UserDatabase) Create(ctx context.Context, m *model.User) (*model.User,
 	return &u, nil
 }
 
+// CreateMany creates new users in the database in bulk with COPY, either all of them or none.
+// The timestamps are stored truncated to microseconds, leaving the given users untouched.
+func (r *UserDatabase) CreateMany(ctx context.Context, ms []*model.User) error {
+	rows := make([][]interface{}, 0, len(ms))
+
+	for _, m := range ms {
+		rows = append(rows, []interface{}{m.ID, m.Name, m.Username, m.Age,
+			m.CreatedAt.Truncate(time.Microsecond), m.UpdatedAt.Truncate(time.Microsecond), m.DeletedAt, m.Version})
+	}
+
+	_, err := database.Copy(ctx, r.cn, r.table,
+		[]string{"id", "name", "username", "age", "created_at", "updated_at", "deleted_at", "version"}, rows)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // GetByID gets an existing user in the database by its ID.
+// It reads from the primary, as a lagging replica would hand out stale versions to conditional requests.
 func (r *UserDatabase) GetByID(ctx context.Context, ID xid.ID) (*model.User, error) {
 	var u model.User
 
-	query := fmt.Sprintf(`SELECT * FROM "%s" WHERE "id" = $1;`, r.table)
-
-	err := pgxutil.SelectStruct(ctx, r.cn, &u, query,
+	err := pgxutil.SelectStruct(ctx, r.cn, &u, userGetByIDStatement,
 		ID)
 	if err != nil {
 		return nil, database.Error(err)
@@ -85,13 +147,37 @@ func (r *UserDatabase) GetByID(ctx context.Context, ID xid.ID) (*model.User, err
 	return &u, nil
 }
 
+// Update updates an existing user in the database only if it is still at m.Version.
+func (r *UserDatabase) Update(ctx context.Context, m *model.User) (*model.User, error) {
+	var u model.User
+
+	err := pgxutil.SelectStruct(ctx, r.cn, &u, userUpdateStatement,
+		m.ID, m.Name, m.Username, m.Age, m.Version)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return &u, nil
+}
+
+// Delete soft deletes an existing user in the database only if it is still at m.Version.
+func (r *UserDatabase) Delete(ctx context.Context, m *model.User) (*model.User, error) {
+	var u model.User
+
+	err := pgxutil.SelectStruct(ctx, r.cn, &u, userDeleteStatement,
+		m.ID, m.Version)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return &u, nil
+}
+
 // List gets existing users from the database with a similar username.
 func (r *UserDatabase) List(ctx context.Context, username string) ([]model.User, error) {
 	var us []model.User
 
-	query := fmt.Sprintf(`SELECT * FROM "%s" WHERE "username" LIKE '%%' || $1 || '%%';`, r.table)
-
-	err := pgxutil.SelectAllStruct(ctx, r.cn, &us, query,
+	err := pgxutil.SelectAllStruct(ctx, r.rd, &us, userListStatement,
 		username)
 	if err != nil {
 		return nil, database.Error(err)
@@ -99,3 +185,39 @@ func (r *UserDatabase) List(ctx context.Context, username string) ([]model.User,
 
 	return us, nil
 }
+
+// Stream iterates over every user in the database without buffering them in memory.
+func (r *UserDatabase) Stream(ctx context.Context, fn func(*model.User) error) error {
+	rows, err := r.rd.Query(ctx, userStreamStatement)
+	if err != nil {
+		return database.Error(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u model.User
+
+		err = rows.Scan(&u.ID, &u.Name, &u.Username, &u.Age, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt, &u.Version)
+		if err != nil {
+			return database.Error(err)
+		}
+
+		if err = fn(&u); err != nil {
+			return err
+		}
+	}
+
+	return database.Error(rows.Err())
+}
+
+// Truncate removes every user from the database, including the soft deleted ones.
+func (r *UserDatabase) Truncate(ctx context.Context) error {
+	query := fmt.Sprintf(`TRUNCATE "%s";`, r.table)
+
+	_, err := r.cn.Exec(ctx, query)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	return nil
+}
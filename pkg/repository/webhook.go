@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgxutil"
+	"github.com/rs/xid"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+)
+
+// Tables of the WebhookDatabase.
+const (
+	webhookSubscriptionsTable = "webhook_subscriptions"
+	webhookDeliveriesTable    = "webhook_deliveries"
+	webhookDeadLettersTable   = "webhook_dead_letters"
+)
+
+// Columns of the WebhookDatabase tables, which its statements select explicitly so that
+// adding a column does not change the result type of the statements prepared on open connections.
+const (
+	webhookSubscriptionColumns = `"id", "url", "secret", "event_types", "created_at", "deleted_at"`
+	webhookDeliveryColumns     = `"id", "subscription_id", "event_id", "event_type", "payload", "status", ` +
+		`"attempts", "response_status", "last_error", "created_at", "next_attempt_at", "delivered_at"`
+)
+
+// Statements of the WebhookDatabase, named after their table and method.
+const (
+	webhookCreateSubscriptionStatement           = "webhook_subscriptions.create"
+	webhookGetSubscriptionByIDStatement          = "webhook_subscriptions.get_by_id"
+	webhookListSubscriptionsStatement            = "webhook_subscriptions.list"
+	webhookListSubscriptionsByEventTypeStatement = "webhook_subscriptions.list_by_event_type"
+	webhookDeleteSubscriptionStatement           = "webhook_subscriptions.delete"
+	webhookCreateDeliveryStatement               = "webhook_deliveries.create"
+	webhookListDeliveriesStatement               = "webhook_deliveries.list"
+	webhookClaimDeliveriesStatement              = "webhook_deliveries.claim"
+	webhookUpdateDeliveryStatement               = "webhook_deliveries.update"
+	webhookCreateDeadLetterStatement             = "webhook_dead_letters.create"
+)
+
+// WebhookRepository interacts with the webhook repository.
+type WebhookRepository interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error, options ...database.TransactionOption) error
+	CreateSubscription(ctx context.Context, m *model.WebhookSubscription) (*model.WebhookSubscription, error)
+	GetSubscriptionByID(ctx context.Context, ID xid.ID) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	ListSubscriptionsByEventType(ctx context.Context, eventType string) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, ID xid.ID) error
+	CreateDelivery(ctx context.Context, m *model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID xid.ID) ([]model.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, until time.Time) ([]model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, m *model.WebhookDelivery) error
+	CreateDeadLetter(ctx context.Context, m *model.WebhookDeadLetter) error
+}
+
+// WebhookDatabase implements a SQL WebhookRepository.
+type WebhookDatabase struct {
+	db                 *database.Database
+	cn                 database.Connection
+	subscriptionsTable string
+	deliveriesTable    string
+	deadLettersTable   string
+}
+
+// NewWebhookDatabase creates a new WebhookDatabase instance.
+func NewWebhookDatabase(db *database.Database) *WebhookDatabase {
+	return &WebhookDatabase{
+		db:                 db,
+		cn:                 db.Writer(),
+		subscriptionsTable: webhookSubscriptionsTable,
+		deliveriesTable:    webhookDeliveriesTable,
+		deadLettersTable:   webhookDeadLettersTable,
+	}
+}
+
+// WebhookStatements returns the statements of the WebhookDatabase, to be prepared on every database connection.
+func WebhookStatements() []database.Statement {
+	return []database.Statement{
+		{Name: webhookCreateSubscriptionStatement, SQL: fmt.Sprintf(
+			`INSERT INTO "%[1]s" (%[2]s)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 RETURNING %[2]s;`, webhookSubscriptionsTable, webhookSubscriptionColumns)},
+		{Name: webhookGetSubscriptionByIDStatement, SQL: fmt.Sprintf(
+			`SELECT %[2]s FROM "%[1]s" WHERE "id" = $1 AND "deleted_at" IS NULL;`,
+			webhookSubscriptionsTable, webhookSubscriptionColumns)},
+		{Name: webhookListSubscriptionsStatement, SQL: fmt.Sprintf(
+			`SELECT %[2]s FROM "%[1]s" WHERE "deleted_at" IS NULL ORDER BY "created_at";`,
+			webhookSubscriptionsTable, webhookSubscriptionColumns)},
+		{Name: webhookListSubscriptionsByEventTypeStatement, SQL: fmt.Sprintf(
+			`SELECT %[2]s FROM "%[1]s"
+			 WHERE $1 = ANY("event_types") AND "deleted_at" IS NULL;`, webhookSubscriptionsTable, webhookSubscriptionColumns)},
+		{Name: webhookDeleteSubscriptionStatement, SQL: fmt.Sprintf(
+			`UPDATE "%s" SET "deleted_at" = NOW()
+			 WHERE "id" = $1 AND "deleted_at" IS NULL;`, webhookSubscriptionsTable)},
+		{Name: webhookCreateDeliveryStatement, SQL: fmt.Sprintf(
+			`INSERT INTO "%s" ("id", "subscription_id", "event_id", "event_type", "payload", "status",
+			                   "attempts", "created_at", "next_attempt_at")
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			 ON CONFLICT ("subscription_id", "event_id") DO NOTHING;`, webhookDeliveriesTable)},
+		{Name: webhookListDeliveriesStatement, SQL: fmt.Sprintf(
+			`SELECT %[2]s FROM "%[1]s"
+			 WHERE "subscription_id" = $1
+			 ORDER BY "created_at" DESC;`, webhookDeliveriesTable, webhookDeliveryColumns)},
+		{Name: webhookClaimDeliveriesStatement, SQL: fmt.Sprintf(
+			`WITH "claimed" AS (
+				 SELECT "d"."id" AS "claimed_id" FROM "%[1]s" "d"
+				 JOIN "%[2]s" "s" ON "s"."id" = "d"."subscription_id" AND "s"."deleted_at" IS NULL
+				 WHERE "d"."status" = $1 AND "d"."next_attempt_at" <= NOW()
+				 ORDER BY "d"."next_attempt_at"
+				 LIMIT $2
+				 FOR UPDATE OF "d" SKIP LOCKED)
+			 UPDATE "%[1]s" SET "next_attempt_at" = $3
+			 FROM "claimed" WHERE "id" = "claimed_id"
+			 RETURNING %[3]s;`, webhookDeliveriesTable, webhookSubscriptionsTable, webhookDeliveryColumns)},
+		{Name: webhookUpdateDeliveryStatement, SQL: fmt.Sprintf(
+			`UPDATE "%s"
+			 SET "status" = $2, "attempts" = $3, "response_status" = $4, "last_error" = $5,
+			     "next_attempt_at" = $6, "delivered_at" = $7
+			 WHERE "id" = $1;`, webhookDeliveriesTable)},
+		{Name: webhookCreateDeadLetterStatement, SQL: fmt.Sprintf(
+			`INSERT INTO "%s" ("id", "delivery_id", "subscription_id", "event_id", "event_type",
+			                   "payload", "attempts", "reason", "created_at")
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`, webhookDeadLettersTable)},
+	}
+}
+
+// Transaction runs fn within a transaction joined by every repository called with its context.
+func (r *WebhookDatabase) Transaction(ctx context.Context, fn func(ctx context.Context) error,
+	options ...database.TransactionOption) error {
+	return r.db.Transaction(ctx, fn, options...)
+}
+
+// CreateSubscription creates a new webhook subscription in the database.
+func (r *WebhookDatabase) CreateSubscription(ctx context.Context,
+	m *model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	var s model.WebhookSubscription
+
+	err := pgxutil.SelectStruct(ctx, r.cn, &s, webhookCreateSubscriptionStatement,
+		m.ID, m.URL, m.Secret, m.EventTypes, m.CreatedAt, m.DeletedAt)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return &s, nil
+}
+
+// GetSubscriptionByID gets an existing webhook subscription in the database by its ID.
+func (r *WebhookDatabase) GetSubscriptionByID(ctx context.Context, ID xid.ID) (*model.WebhookSubscription, error) {
+	var s model.WebhookSubscription
+
+	err := pgxutil.SelectStruct(ctx, r.cn, &s, webhookGetSubscriptionByIDStatement,
+		ID)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return &s, nil
+}
+
+// ListSubscriptions gets all existing webhook subscriptions from the database.
+func (r *WebhookDatabase) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	var ss []model.WebhookSubscription
+
+	err := pgxutil.SelectAllStruct(ctx, r.cn, &ss, webhookListSubscriptionsStatement)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return ss, nil
+}
+
+// ListSubscriptionsByEventType gets the existing webhook subscriptions to an event type from the database.
+func (r *WebhookDatabase) ListSubscriptionsByEventType(ctx context.Context,
+	eventType string) ([]model.WebhookSubscription, error) {
+	var ss []model.WebhookSubscription
+
+	err := pgxutil.SelectAllStruct(ctx, r.cn, &ss, webhookListSubscriptionsByEventTypeStatement,
+		eventType)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return ss, nil
+}
+
+// DeleteSubscription soft deletes an existing webhook subscription in the database.
+func (r *WebhookDatabase) DeleteSubscription(ctx context.Context, ID xid.ID) error {
+	tag, err := r.cn.Exec(ctx, webhookDeleteSubscriptionStatement,
+		ID)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return database.ErrNoRows
+	}
+
+	return nil
+}
+
+// CreateDelivery creates a new webhook delivery in the database, unless the event was already
+// scheduled for the subscription.
+func (r *WebhookDatabase) CreateDelivery(ctx context.Context, m *model.WebhookDelivery) error {
+	_, err := r.cn.Exec(ctx, webhookCreateDeliveryStatement,
+		m.ID, m.SubscriptionID, m.EventID, m.EventType, m.Payload, m.Status, m.Attempts, m.CreatedAt, m.NextAttemptAt)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// ListDeliveries gets the delivery history of a webhook subscription from the database.
+func (r *WebhookDatabase) ListDeliveries(ctx context.Context, subscriptionID xid.ID) ([]model.WebhookDelivery, error) {
+	var ds []model.WebhookDelivery
+
+	err := pgxutil.SelectAllStruct(ctx, r.cn, &ds, webhookListDeliveriesStatement,
+		subscriptionID)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	return ds, nil
+}
+
+// ClaimDeliveries gets the oldest pending deliveries of active subscriptions that are due, postponing their
+// next attempt until the given time so that no one else claims them meanwhile, sorted by creation.
+// Deliveries being claimed by someone else are skipped.
+func (r *WebhookDatabase) ClaimDeliveries(ctx context.Context, limit int,
+	until time.Time) ([]model.WebhookDelivery, error) {
+	var ds []model.WebhookDelivery
+
+	err := pgxutil.SelectAllStruct(ctx, r.cn, &ds, webhookClaimDeliveriesStatement,
+		model.WebhookDeliveryStatuses.PENDING, limit, until)
+	if err != nil {
+		return nil, database.Error(err)
+	}
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].CreatedAt.Before(ds[j].CreatedAt) })
+
+	return ds, nil
+}
+
+// UpdateDelivery updates the status of an existing webhook delivery in the database.
+func (r *WebhookDatabase) UpdateDelivery(ctx context.Context, m *model.WebhookDelivery) error {
+	_, err := r.cn.Exec(ctx, webhookUpdateDeliveryStatement,
+		m.ID, m.Status, m.Attempts, m.ResponseStatus, m.LastError, m.NextAttemptAt, m.DeliveredAt)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// CreateDeadLetter creates a new webhook dead letter in the database.
+func (r *WebhookDatabase) CreateDeadLetter(ctx context.Context, m *model.WebhookDeadLetter) error {
+	_, err := r.cn.Exec(ctx, webhookCreateDeadLetterStatement,
+		m.ID, m.DeliveryID, m.SubscriptionID, m.EventID, m.EventType, m.Payload, m.Attempts, m.Reason, m.CreatedAt)
+	if err != nil {
+		return database.Error(err)
+	}
+
+	return nil
+}
@@ -8,38 +8,55 @@ import (
 	"github.com/neoxelox/zeus/internal/database"
 	"github.com/neoxelox/zeus/pkg/model"
 	"github.com/neoxelox/zeus/pkg/repository"
+	"github.com/neoxelox/zeus/pkg/webhook"
 )
 
 // CreatorUseCase interacts with the user creator use case.
 type CreatorUseCase interface {
 	Create(ctx context.Context, name string, username string, age int) (*model.User, error)
+	CreateMany(ctx context.Context, users []*model.User) error
 }
 
 // Creator implements the CreatorUseCase.
 type Creator struct {
-	userRepository repository.UserRepository
+	userRepository    repository.UserRepository
+	outboxRepository  repository.OutboxRepository
+	webhookDispatcher webhook.DispatcherUseCase
 }
 
 // NewCreator creates a new Creator instance.
-func NewCreator(userRepository repository.UserRepository) *Creator {
+func NewCreator(userRepository repository.UserRepository, outboxRepository repository.OutboxRepository,
+	webhookDispatcher webhook.DispatcherUseCase) *Creator {
 	return &Creator{
-		userRepository: userRepository,
+		userRepository:    userRepository,
+		outboxRepository:  outboxRepository,
+		webhookDispatcher: webhookDispatcher,
 	}
 }
 
 // Create creates a new user.
 func (c *Creator) Create(ctx context.Context, name string, username string, age int) (*model.User, error) {
 	if age < model.UserMinAge {
-		return nil, model.ErrUserBelowAge.New("Cannot create user underaged")
+		return nil, model.ErrUserBelowAge.With("min_age", model.UserMinAge).New("Cannot create user underaged")
 	}
 
-	user := model.NewUser(name, username, age)
+	var user *model.User
 
-	user, err := c.userRepository.Create(ctx, user)
+	err := c.userRepository.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		user, err = c.userRepository.Create(ctx, model.NewUser(name, username, age))
+		if err != nil {
+			return err
+		}
+
+		return emit(ctx, c.outboxRepository, c.webhookDispatcher, model.EventTypes.UserCreated, user)
+	})
 	if err != nil {
 		switch {
-		case errors.Is(err, database.ErrIntegrityViolation):
-			return nil, model.ErrExistingUsername.Wrap(err, "Cannot create user with existing username")
+		case violates(err, database.ErrUniqueViolation, repository.UserUsernameConstraint):
+			return nil, model.ErrExistingUsername.With("username", username).Wrap(err,
+				"Cannot create user with existing username")
 		default:
 			return nil, errors.Wrap(err, "Cannot create user")
 		}
@@ -47,3 +64,37 @@ func (c *Creator) Create(ctx context.Context, name string, username string, age
 
 	return user, nil
 }
+
+// CreateMany creates new users in bulk, either all of them or none.
+func (c *Creator) CreateMany(ctx context.Context, users []*model.User) error {
+	for _, user := range users {
+		if user.Age < model.UserMinAge {
+			return model.ErrUserBelowAge.With("min_age", model.UserMinAge).New("Cannot create users underaged")
+		}
+	}
+
+	err := c.userRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := c.userRepository.CreateMany(ctx, users); err != nil {
+			return err
+		}
+
+		return emitMany(ctx, c.outboxRepository, c.webhookDispatcher, model.EventTypes.UserCreated, users)
+	})
+	if err != nil {
+		switch {
+		case violates(err, database.ErrUniqueViolation, repository.UserUsernameConstraint):
+			return model.ErrExistingUsername.Wrap(err, "Cannot create users with existing usernames")
+		default:
+			return errors.Wrap(err, "Cannot create users")
+		}
+	}
+
+	return nil
+}
+
+// violates checks whether err is the given violation of the given constraint.
+func violates(err error, violation error, constraint string) bool {
+	var cerr *database.ConstraintError
+
+	return errors.As(err, &cerr) && errors.Is(cerr, violation) && cerr.Constraint == constraint
+}
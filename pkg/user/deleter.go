@@ -0,0 +1,72 @@
+package user
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rs/xid"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+	"github.com/neoxelox/zeus/pkg/webhook"
+)
+
+// DeleterUseCase interacts with the user deleter use case.
+type DeleterUseCase interface {
+	Delete(ctx context.Context, ID xid.ID, version string) error
+}
+
+// Deleter implements the DeleterUseCase.
+type Deleter struct {
+	userRepository    repository.UserRepository
+	outboxRepository  repository.OutboxRepository
+	webhookDispatcher webhook.DispatcherUseCase
+}
+
+// NewDeleter creates a new Deleter instance.
+func NewDeleter(userRepository repository.UserRepository, outboxRepository repository.OutboxRepository,
+	webhookDispatcher webhook.DispatcherUseCase) *Deleter {
+	return &Deleter{
+		userRepository:    userRepository,
+		outboxRepository:  outboxRepository,
+		webhookDispatcher: webhookDispatcher,
+	}
+}
+
+// Delete deletes an existing user only if its current version matches the given one,
+// either * or a list of entity tags as sent in the If-Match header.
+func (d *Deleter) Delete(ctx context.Context, ID xid.ID, version string) error {
+	found := false
+
+	err := d.userRepository.Transaction(ctx, func(ctx context.Context) error {
+		current, err := d.userRepository.GetByID(ctx, ID)
+		found = err == nil
+		if err != nil {
+			return err
+		}
+
+		if !current.MatchETag(version) {
+			return model.ErrUserVersionMismatch.New("Cannot delete user modified since the given version")
+		}
+
+		user, err := d.userRepository.Delete(ctx, current)
+		if err != nil {
+			return err
+		}
+
+		return emit(ctx, d.outboxRepository, d.webhookDispatcher, model.EventTypes.UserDeleted, user)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNoRows) && !found:
+			return model.ErrUserNotExists.Wrap(err, "Cannot delete a user with that id")
+		case errors.Is(err, database.ErrNoRows), errors.Is(err, model.ErrUserVersionMismatch):
+			return model.ErrUserVersionMismatch.Wrap(err, "Cannot delete user modified since the given version")
+		default:
+			return errors.Wrap(err, "Cannot delete user")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package user
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+	"github.com/neoxelox/zeus/pkg/webhook"
+)
+
+// emit writes a user lifecycle event to the outbox and schedules its webhook deliveries, which must be
+// called within the transaction of the user change for both to be committed alongside it.
+func emit(ctx context.Context, outboxRepository repository.OutboxRepository,
+	webhookDispatcher webhook.DispatcherUseCase, eventType string, user *model.User) error {
+	event, err := model.NewEvent(eventType, user.ID, model.NewUserEventPayload(user))
+	if err != nil {
+		return errors.Wrap(err, "Cannot create user event")
+	}
+
+	_, err = outboxRepository.Create(ctx, event)
+	if err != nil {
+		return errors.Wrap(err, "Cannot add user event to the outbox")
+	}
+
+	return webhookDispatcher.Publish(ctx, event)
+}
+
+// emitMany writes a user lifecycle event of each user to the outbox in a single batch and schedules
+// their webhook deliveries, which must be called within the transaction of the user changes.
+func emitMany(ctx context.Context, outboxRepository repository.OutboxRepository,
+	webhookDispatcher webhook.DispatcherUseCase, eventType string, users []*model.User) error {
+	events := make([]*model.Event, 0, len(users))
+
+	for _, user := range users {
+		event, err := model.NewEvent(eventType, user.ID, model.NewUserEventPayload(user))
+		if err != nil {
+			return errors.Wrap(err, "Cannot create user event")
+		}
+
+		events = append(events, event)
+	}
+
+	if err := outboxRepository.CreateMany(ctx, events); err != nil {
+		return errors.Wrap(err, "Cannot add user events to the outbox")
+	}
+
+	return webhookDispatcher.PublishMany(ctx, events)
+}
@@ -0,0 +1,44 @@
+package user
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+)
+
+// ExporterUseCase interacts with the user exporter use case.
+type ExporterUseCase interface {
+	Export(ctx context.Context, fn func(*model.User) error) error
+}
+
+// Exporter implements the ExporterUseCase.
+type Exporter struct {
+	userRepository repository.UserRepository
+}
+
+// NewExporter creates a new Exporter instance.
+func NewExporter(userRepository repository.UserRepository) *Exporter {
+	return &Exporter{
+		userRepository: userRepository,
+	}
+}
+
+// Export streams every existing user to fn from a consistent snapshot.
+// The query is not bounded by the default timeout, as it lasts as long as the client consumes it,
+// and it is never retried, as the users already streamed cannot be taken back.
+func (e *Exporter) Export(ctx context.Context, fn func(*model.User) error) error {
+	ctx = database.WithQueryTimeout(ctx, 0)
+
+	err := e.userRepository.Transaction(ctx, func(ctx context.Context) error {
+		return e.userRepository.Stream(ctx, fn)
+	}, database.ReadOnly(), database.Deferrable(), database.NoRetry())
+	if err != nil {
+		return errors.Wrap(err, "Cannot export users")
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+)
+
+// SeederUseCase interacts with the user seeder use case.
+type SeederUseCase interface {
+	Seed(ctx context.Context, users []*model.User, truncate bool) error
+	Generate(n int) []*model.User
+}
+
+// Seeder implements the SeederUseCase.
+type Seeder struct {
+	userRepository repository.UserRepository
+}
+
+// NewSeeder creates a new Seeder instance.
+func NewSeeder(userRepository repository.UserRepository) *Seeder {
+	return &Seeder{
+		userRepository: userRepository,
+	}
+}
+
+// Seed creates the given users in bulk with the same validation as any other user, removing
+// every existing user first if truncate is set, either all of it or nothing. Unlike the creator,
+// neither the seeded nor the truncated users emit events, so seed data never reaches the outbox
+// or the webhook subscribers.
+func (s *Seeder) Seed(ctx context.Context, users []*model.User, truncate bool) error {
+	for _, user := range users {
+		if user.Age < model.UserMinAge {
+			return model.ErrUserBelowAge.With("min_age", model.UserMinAge).New("Cannot seed users underaged")
+		}
+	}
+
+	err := s.userRepository.Transaction(ctx, func(ctx context.Context) error {
+		if truncate {
+			if err := s.userRepository.Truncate(ctx); err != nil {
+				return err
+			}
+		}
+
+		return s.userRepository.CreateMany(ctx, users)
+	})
+	if err != nil {
+		switch {
+		case violates(err, database.ErrUniqueViolation, repository.UserUsernameConstraint):
+			return model.ErrExistingUsername.Wrap(err, "Cannot seed users with existing usernames")
+		default:
+			return errors.Wrap(err, "Cannot seed users")
+		}
+	}
+
+	return nil
+}
+
+var (
+	seedFirstNames = []string{
+		"Alex", "Maria", "John", "Laura", "David", "Marta", "Pau", "Julia", "Daniel", "Paula",
+		"Sergio", "Lucia", "Marc", "Sara", "Pablo", "Elena", "Hugo", "Carla", "Jordi", "Ana",
+	}
+	seedLastNames = []string{
+		"Garcia", "Smith", "Martinez", "Johnson", "Lopez", "Brown", "Sanchez", "Miller", "Perez", "Davis",
+		"Gomez", "Wilson", "Fernandez", "Moore", "Ruiz", "Taylor", "Diaz", "Thomas", "Moreno", "White",
+	}
+)
+
+// seedMaxAge maximum age of the generated users.
+const seedMaxAge = 90
+
+// Generate creates n random valid users of at least UserMinAge with unique usernames.
+func (s *Seeder) Generate(n int) []*model.User {
+	random := rand.New(rand.NewSource(time.Now().UnixNano())) // nolint
+	users := make([]*model.User, 0, n)
+	usernames := make(map[string]bool, n)
+
+	for len(users) < n {
+		first := seedFirstNames[random.Intn(len(seedFirstNames))]
+		last := seedLastNames[random.Intn(len(seedLastNames))]
+
+		username := fmt.Sprintf("%s.%s.%06x", strings.ToLower(first), strings.ToLower(last), random.Intn(1<<24))
+		if usernames[username] {
+			continue
+		}
+
+		usernames[username] = true
+
+		age := model.UserMinAge + random.Intn(seedMaxAge-model.UserMinAge+1)
+
+		users = append(users, model.NewUser(first+" "+last, username, age))
+	}
+
+	return users
+}
@@ -0,0 +1,86 @@
+package user
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rs/xid"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+	"github.com/neoxelox/zeus/pkg/webhook"
+)
+
+// UpdaterUseCase interacts with the user updater use case.
+type UpdaterUseCase interface {
+	Update(ctx context.Context, ID xid.ID, version string, name string, username string, age int) (*model.User, error)
+}
+
+// Updater implements the UpdaterUseCase.
+type Updater struct {
+	userRepository    repository.UserRepository
+	outboxRepository  repository.OutboxRepository
+	webhookDispatcher webhook.DispatcherUseCase
+}
+
+// NewUpdater creates a new Updater instance.
+func NewUpdater(userRepository repository.UserRepository, outboxRepository repository.OutboxRepository,
+	webhookDispatcher webhook.DispatcherUseCase) *Updater {
+	return &Updater{
+		userRepository:    userRepository,
+		outboxRepository:  outboxRepository,
+		webhookDispatcher: webhookDispatcher,
+	}
+}
+
+// Update updates an existing user only if its current version matches the given one,
+// either * or a list of entity tags as sent in the If-Match header.
+func (u *Updater) Update(ctx context.Context, ID xid.ID, version string,
+	name string, username string, age int) (*model.User, error) {
+	if age < model.UserMinAge {
+		return nil, model.ErrUserBelowAge.With("min_age", model.UserMinAge).New("Cannot update user underaged")
+	}
+
+	var user *model.User
+
+	found := false
+
+	err := u.userRepository.Transaction(ctx, func(ctx context.Context) error {
+		current, err := u.userRepository.GetByID(ctx, ID)
+		found = err == nil
+		if err != nil {
+			return err
+		}
+
+		if !current.MatchETag(version) {
+			return model.ErrUserVersionMismatch.New("Cannot update user modified since the given version")
+		}
+
+		current.Name = name
+		current.Username = username
+		current.Age = age
+
+		user, err = u.userRepository.Update(ctx, current)
+		if err != nil {
+			return err
+		}
+
+		return emit(ctx, u.outboxRepository, u.webhookDispatcher, model.EventTypes.UserUpdated, user)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNoRows) && !found:
+			return nil, model.ErrUserNotExists.Wrap(err, "Cannot update a user with that id")
+		case errors.Is(err, database.ErrNoRows), errors.Is(err, model.ErrUserVersionMismatch):
+			return nil, model.ErrUserVersionMismatch.Wrap(err, "Cannot update user modified since the given version")
+		case violates(err, database.ErrUniqueViolation, repository.UserUsernameConstraint):
+			return nil, model.ErrExistingUsername.With("username", username).Wrap(err,
+				"Cannot update user with existing username")
+		default:
+			return nil, errors.Wrap(err, "Cannot update user")
+		}
+	}
+
+	return user, nil
+}
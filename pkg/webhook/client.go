@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ClientConfiguration describes the configuration of the client webhooks are delivered with.
+// AllowPrivate permits plain HTTP and loopback, link-local and private destinations, for development.
+type ClientConfiguration struct {
+	Timeout      time.Duration
+	AllowPrivate bool
+}
+
+// NewClient creates the HTTP client webhooks are delivered with, which does not follow redirects nor use
+// proxies and refuses to connect to non public addresses unless AllowPrivate is set. Addresses are checked
+// when dialing, after DNS resolution, so that a subscription host cannot be repointed inside the network.
+func NewClient(configuration ClientConfiguration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: configuration.Timeout,
+	}
+
+	if !configuration.AllowPrivate {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errors.Wrapf(err, "Cannot parse webhook destination %s", address)
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !public(ip) {
+				return errors.Newf("Cannot deliver webhooks to the non public address %s", host)
+			}
+
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: configuration.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   configuration.Timeout,
+			ResponseHeaderTimeout: configuration.Timeout,
+			MaxIdleConns:          100,              // nolint
+			IdleConnTimeout:       90 * time.Second, // nolint
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ValidateURL checks that rawURL is an absolute HTTPS URL whose host is not a loopback,
+// link-local or private address, unless allowPrivate is set.
+func ValidateURL(rawURL string, allowPrivate bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.Wrap(err, "Cannot parse webhook URL")
+	}
+
+	if u.Host == "" || (u.Scheme != "https" && (!allowPrivate || u.Scheme != "http")) {
+		return errors.Newf("Webhook URL %s must be an absolute HTTPS URL", rawURL)
+	}
+
+	if allowPrivate {
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.Newf("Webhook URL %s must point to a public host", rawURL)
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !public(ip) {
+		return errors.Newf("Webhook URL %s must point to a public host", rawURL)
+	}
+
+	return nil
+}
+
+// privateNetworks are the unicast networks that are not publicly routable, such as RFC 1918 ones.
+var privateNetworks = func() []*net.IPNet {
+	cidrs := []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"}
+	networks := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+
+	return networks
+}()
+
+// public checks whether ip is a publicly routable unicast address, excluding
+// the unspecified, loopback, link-local, multicast and private ones.
+func public(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() {
+		return false
+	}
+
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
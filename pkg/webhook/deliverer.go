@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+)
+
+// DelivererUseCase interacts with the webhook deliverer use case.
+type DelivererUseCase interface {
+	Deliver(ctx context.Context) (int, error)
+}
+
+// DelivererConfiguration describes the Deliverer configuration.
+// Lease is how long claimed deliveries wait for their outcome before being claimed again,
+// so it should be longer than sending a whole batch.
+type DelivererConfiguration struct {
+	BatchSize   int
+	MaxAttempts int
+	Lease       time.Duration
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Deliverer implements the DelivererUseCase.
+type Deliverer struct {
+	webhookRepository repository.WebhookRepository
+	client            *http.Client
+	configuration     DelivererConfiguration
+}
+
+// NewDeliverer creates a new Deliverer instance.
+func NewDeliverer(webhookRepository repository.WebhookRepository, client *http.Client,
+	configuration DelivererConfiguration) *Deliverer {
+	return &Deliverer{
+		webhookRepository: webhookRepository,
+		client:            client,
+		configuration:     configuration,
+	}
+}
+
+// Deliver sends a batch of pending deliveries, returning how many were attempted.
+// Deliveries are claimed beforehand and sent without holding any lock or transaction, recording each
+// outcome on its own, so a failure only resends the deliveries whose outcome was not recorded.
+// Failed deliveries are retried with exponential backoff until MaxAttempts, then dead lettered.
+func (d *Deliverer) Deliver(ctx context.Context) (int, error) {
+	deliveries, err := d.webhookRepository.ClaimDeliveries(ctx, d.configuration.BatchSize,
+		time.Now().Add(d.configuration.Lease))
+	if err != nil {
+		return 0, errors.Wrap(err, "Cannot claim webhook deliveries")
+	}
+
+	attempted := 0
+	subscriptions := map[string]*model.WebhookSubscription{}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		subscription, ok := subscriptions[delivery.SubscriptionID.String()]
+		if !ok {
+			subscription, err = d.webhookRepository.GetSubscriptionByID(ctx, delivery.SubscriptionID)
+			if err != nil {
+				return attempted, errors.Wrap(err, "Cannot deliver webhooks")
+			}
+			subscriptions[delivery.SubscriptionID.String()] = subscription
+		}
+
+		if err = d.attempt(ctx, subscription, delivery); err != nil {
+			return attempted, errors.Wrap(err, "Cannot deliver webhooks")
+		}
+
+		attempted++
+	}
+
+	return attempted, nil
+}
+
+// attempt sends a delivery once and records its outcome in its own transaction.
+func (d *Deliverer) attempt(ctx context.Context, subscription *model.WebhookSubscription,
+	delivery *model.WebhookDelivery) error {
+	status, err := d.send(ctx, subscription, delivery)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+
+	if err == nil {
+		delivery.Status = model.WebhookDeliveryStatuses.DELIVERED
+		delivery.LastError = nil
+		delivery.DeliveredAt = &now
+
+		return d.webhookRepository.UpdateDelivery(ctx, delivery)
+	}
+
+	reason := err.Error()
+	delivery.LastError = &reason
+
+	if delivery.Attempts < d.configuration.MaxAttempts {
+		delivery.NextAttemptAt = now.Add(
+			database.Backoff(delivery.Attempts, d.configuration.MinBackoff, d.configuration.MaxBackoff))
+
+		return d.webhookRepository.UpdateDelivery(ctx, delivery)
+	}
+
+	delivery.Status = model.WebhookDeliveryStatuses.FAILED
+
+	return d.webhookRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := d.webhookRepository.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		return d.webhookRepository.CreateDeadLetter(ctx, model.NewWebhookDeadLetter(delivery, reason))
+	})
+}
+
+// send posts the signed delivery to the subscription URL, failing on any non 2XX response.
+func (d *Deliverer) send(ctx context.Context, subscription *model.WebhookSubscription,
+	delivery *model.WebhookDelivery) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot create webhook request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, time.Now(), delivery.Payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot send webhook request")
+	}
+	defer res.Body.Close()
+
+	io.Copy(ioutil.Discard, res.Body) // nolint
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return &res.StatusCode, errors.Newf("Webhook responded with status %d", res.StatusCode)
+	}
+
+	return &res.StatusCode, nil
+}
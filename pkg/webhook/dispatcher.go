@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+)
+
+// DispatcherUseCase interacts with the webhook dispatcher use case.
+type DispatcherUseCase interface {
+	Publish(ctx context.Context, event *model.Event) error
+	PublishMany(ctx context.Context, events []*model.Event) error
+}
+
+// Dispatcher implements the DispatcherUseCase. The user use cases call it within the transaction
+// of each user change, so that deliveries are scheduled if and only if the change is committed.
+type Dispatcher struct {
+	webhookRepository repository.WebhookRepository
+}
+
+// NewDispatcher creates a new Dispatcher instance.
+func NewDispatcher(webhookRepository repository.WebhookRepository) *Dispatcher {
+	return &Dispatcher{
+		webhookRepository: webhookRepository,
+	}
+}
+
+// Publish schedules a delivery of the event for every subscription to its type.
+// Publishing the same event twice does not schedule duplicated deliveries.
+func (d *Dispatcher) Publish(ctx context.Context, event *model.Event) error {
+	return d.PublishMany(ctx, []*model.Event{event})
+}
+
+// PublishMany schedules a delivery of each event for every subscription to its type,
+// listing the subscriptions to each type only once.
+func (d *Dispatcher) PublishMany(ctx context.Context, events []*model.Event) error {
+	err := d.webhookRepository.Transaction(ctx, func(ctx context.Context) error {
+		subscriptions := map[string][]model.WebhookSubscription{}
+
+		for _, event := range events {
+			if _, ok := subscriptions[event.Type]; !ok {
+				list, err := d.webhookRepository.ListSubscriptionsByEventType(ctx, event.Type)
+				if err != nil {
+					return err
+				}
+
+				subscriptions[event.Type] = list
+			}
+
+			for _, subscription := range subscriptions[event.Type] {
+				delivery, err := model.NewWebhookDelivery(subscription.ID, event)
+				if err != nil {
+					return err // nolint
+				}
+
+				if err = d.webhookRepository.CreateDelivery(ctx, delivery); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Cannot dispatch event to webhooks")
+	}
+
+	return nil
+}
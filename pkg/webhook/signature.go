@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// HeaderSignature carries the timestamp and HMAC-SHA256 signature of a delivery.
+	HeaderSignature = "Zeus-Signature"
+
+	// HeaderEvent carries the event type of a delivery.
+	HeaderEvent = "Zeus-Event"
+
+	// HeaderDelivery carries the ID of a delivery, which is kept between retries.
+	HeaderDelivery = "Zeus-Delivery"
+)
+
+// Sign computes the signature header of a delivery body at the given time.
+// The signed content is the unix timestamp and the body joined by a dot, so that
+// receivers can reject replayed deliveries by checking the timestamp freshness.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix)) // nolint
+	mac.Write([]byte("."))  // nolint
+	mac.Write(body)         // nolint
+
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(mac.Sum(nil)))
+}
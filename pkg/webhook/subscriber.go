@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rs/xid"
+
+	"github.com/neoxelox/zeus/internal/database"
+	"github.com/neoxelox/zeus/pkg/model"
+	"github.com/neoxelox/zeus/pkg/repository"
+)
+
+// SubscriberUseCase interacts with the webhook subscriber use case.
+type SubscriberUseCase interface {
+	Subscribe(ctx context.Context, url string, eventTypes []string) (*model.WebhookSubscription, error)
+	List(ctx context.Context) ([]model.WebhookSubscription, error)
+	Unsubscribe(ctx context.Context, ID xid.ID) error
+	ListDeliveries(ctx context.Context, ID xid.ID) ([]model.WebhookDelivery, error)
+}
+
+// SubscriberConfiguration describes the Subscriber configuration.
+// AllowPrivate accepts plain HTTP URLs and non public hosts, for development.
+type SubscriberConfiguration struct {
+	AllowPrivate bool
+}
+
+// Subscriber implements the SubscriberUseCase.
+type Subscriber struct {
+	webhookRepository repository.WebhookRepository
+	configuration     SubscriberConfiguration
+}
+
+// NewSubscriber creates a new Subscriber instance.
+func NewSubscriber(webhookRepository repository.WebhookRepository,
+	configuration SubscriberConfiguration) *Subscriber {
+	return &Subscriber{
+		webhookRepository: webhookRepository,
+		configuration:     configuration,
+	}
+}
+
+// Subscribe registers a new webhook subscription to the given event types.
+// The URL must be HTTPS and point to a public host unless AllowPrivate is set.
+func (s *Subscriber) Subscribe(ctx context.Context, url string,
+	eventTypes []string) (*model.WebhookSubscription, error) {
+	if err := ValidateURL(url, s.configuration.AllowPrivate); err != nil {
+		return nil, model.ErrWebhookInvalidURL.Wrap(err, "Cannot subscribe webhook to that URL")
+	}
+
+	subscription, err := model.NewWebhookSubscription(url, eventTypes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot generate webhook subscription")
+	}
+
+	created, err := s.webhookRepository.CreateSubscription(ctx, subscription)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot create webhook subscription")
+	}
+
+	return created, nil
+}
+
+// List gets the existing webhook subscriptions.
+func (s *Subscriber) List(ctx context.Context) ([]model.WebhookSubscription, error) {
+	subscriptions, err := s.webhookRepository.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list webhook subscriptions")
+	}
+
+	return subscriptions, nil
+}
+
+// Unsubscribe deletes an existing webhook subscription.
+func (s *Subscriber) Unsubscribe(ctx context.Context, ID xid.ID) error {
+	err := s.webhookRepository.DeleteSubscription(ctx, ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNoRows):
+			return model.ErrWebhookNotExists.Wrap(err, "Cannot delete a webhook with that id")
+		default:
+			return errors.Wrap(err, "Cannot delete webhook subscription")
+		}
+	}
+
+	return nil
+}
+
+// ListDeliveries gets the delivery history of an existing webhook subscription.
+func (s *Subscriber) ListDeliveries(ctx context.Context, ID xid.ID) ([]model.WebhookDelivery, error) {
+	_, err := s.webhookRepository.GetSubscriptionByID(ctx, ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNoRows):
+			return nil, model.ErrWebhookNotExists.Wrap(err, "Cannot get a webhook with that id")
+		default:
+			return nil, errors.Wrap(err, "Cannot get webhook subscription")
+		}
+	}
+
+	deliveries, err := s.webhookRepository.ListDeliveries(ctx, ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list webhook deliveries")
+	}
+
+	return deliveries, nil
+}
@@ -13,9 +13,6 @@ LINTER = f"{GOPATH}/bin/golangci-lint"
 TESTER_VERSION = "1.6.2"
 TESTER = f"{GOPATH}/bin/gotestsum"
 
-MIGRATOR_VERSION = "4.14.1"
-MIGRATOR = f"{GOPATH}/bin/migrate"
-
 CURRENT = "zeus"
 SERVICES = ["postgres"]
 
@@ -132,14 +129,12 @@ def devtools(c, yes=False):
     def installed():
         tester = "dev" in c.run(f"{TESTER} --version", warn=True, hide="both").stdout
         linter = LINTER_VERSION in c.run(f"{LINTER} --version", warn=True, hide="both").stdout
-        migrator = "dev" in c.run(f"{MIGRATOR} --version", warn=True, hide="both").stderr
-        return tester and linter and migrator
+        return tester and linter
 
     if not installed():
         if not yes and input("Devtools not installed, install? y/n: ").lower() != "y":
             fail("Aborting as devtools not installed!")
 
-        c.run(f"go install -tags 'postgres' github.com/golang-migrate/migrate/v4/cmd/migrate@v{MIGRATOR_VERSION}")
         c.run(f"go install gotest.tools/gotestsum@v{TESTER_VERSION}")
         c.run(
             f"curl -sSfL https://raw.githubusercontent.com/golangci/golangci-lint/master/install.sh | sudo sh -s -- -b {GOPATH}/bin v{LINTER_VERSION}"
@@ -160,16 +155,26 @@ def lint(c, fix=False, yes=False):
     devtools(c, yes=yes)
 
     c.run(f"{LINTER} run ./... -c .golangci.yaml {'--fix' if fix else ''}")
+    c.run("go run ./cmd/zeus migrate -dir migrations lint")
 
 
 @task(
     help={
         "name": "Migration name.",
-        "yes": "Automatically say yes to the following questions.",
     }
 )
-def migrate(c, name, yes=False):
+def migrate(c, name):
     """Create a migration."""
-    devtools(c, yes=yes)
+    c.run(f"go run ./cmd/zeus migrate create {name}")
 
-    c.run(f"{MIGRATOR} create -ext sql -dir migrations -seq -digits 4 {name}")
+
+@task(
+    help={
+        "users": "Number of random users to generate.",
+        "truncate": "Remove every existing user before seeding.",
+    }
+)
+def seed(c, users=0, truncate=False):
+    """Seed the database with the fixtures and random users."""
+    load_dotenv(dotenv_path="./development.env")
+    c.run(f"go run ./cmd/zeus seed {'-truncate' if truncate else ''} -users {users} fixtures/users.yaml")